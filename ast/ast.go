@@ -5,8 +5,12 @@ import (
 	"pascal_in_go/token"
 )
 
+//Span represents the source range a node was parsed from, see token.Span
+type Span = token.Span
+
 //BinNode represents the binary expr
 type BinNode struct {
+	Span
 	Left  Expr
 	Right Expr
 	Tok   token.Token
@@ -14,12 +18,28 @@ type BinNode struct {
 
 //NumNode holds the number of token
 type NumNode struct {
+	Span
+	Tok   token.Token `json:"tok"`
+	Value string      `json:"value"`
+}
+
+//StringNode holds a string or char literal, Value is the text without quotes
+type StringNode struct {
+	Span
 	Tok   token.Token `json:"tok"`
 	Value string      `json:"value"`
 }
 
+//BoolNode holds the TRUE or FALSE literal
+type BoolNode struct {
+	Span
+	Tok   token.Token `json:"tok"`
+	Value bool        `json:"value"`
+}
+
 //VarNode represents the Variable node
 type VarNode struct {
+	Span
 	Tok     token.Token
 	Literal string
 }
@@ -27,10 +47,12 @@ type VarNode struct {
 //Expr interface represent the expr,  expr is the unit for program
 type Expr interface {
 	ToStr() string
+	GetSpan() Span
 }
 
 //Unary Node
 type Unary struct {
+	Span
 	Op   string
 	Expr Expr
 }
@@ -48,6 +70,16 @@ func (numNode NumNode) ToStr() string {
 	return fmt.Sprint(numNode.Tok)
 }
 
+//ToStr for StringNode
+func (strNode StringNode) ToStr() string {
+	return fmt.Sprint(strNode.Tok)
+}
+
+//ToStr for BoolNode
+func (boolNode BoolNode) ToStr() string {
+	return fmt.Sprint(boolNode.Tok)
+}
+
 //ToStr for UnaryNode
 func (unary Unary) ToStr() string {
 	return fmt.Sprint(unary)
@@ -59,6 +91,7 @@ func (va VarNode) ToStr() string {
 }
 
 type AssignStatement struct {
+	Span
 	Left  VarNode
 	Op    token.Token
 	Right Expr
@@ -69,6 +102,7 @@ func (asg AssignStatement) ToStr() string {
 }
 
 type Compound struct {
+	Span
 	Children []Expr
 }
 
@@ -77,6 +111,7 @@ func (compound Compound) ToStr() string {
 }
 
 type Statement struct {
+	Span
 	Statement Expr
 }
 
@@ -84,16 +119,109 @@ func (st Statement) ToStr() string {
 	return fmt.Sprint(st)
 }
 
+//IfStatement represents IF Cond THEN Then ELSE Else, Else is nil without ELSE
+type IfStatement struct {
+	Span
+	Cond Expr
+	Then Expr
+	Else Expr
+}
+
+func (ifSt IfStatement) ToStr() string {
+	return fmt.Sprint(ifSt)
+}
+
+//WhileStatement represents WHILE Cond DO Body
+type WhileStatement struct {
+	Span
+	Cond Expr
+	Body Expr
+}
+
+func (whileSt WhileStatement) ToStr() string {
+	return fmt.Sprint(whileSt)
+}
+
+//RepeatStatement represents REPEAT Body UNTIL Cond, Body is a statement list
+type RepeatStatement struct {
+	Span
+	Body []Expr
+	Cond Expr
+}
+
+func (repeatSt RepeatStatement) ToStr() string {
+	return fmt.Sprint(repeatSt)
+}
+
+//ForStatement represents FOR Var := Start TO Stop DO Body,
+//Down is true for DOWNTO
+type ForStatement struct {
+	Span
+	Var   VarNode
+	Start Expr
+	Stop  Expr
+	Down  bool
+	Body  Expr
+}
+
+func (forSt ForStatement) ToStr() string {
+	return fmt.Sprint(forSt)
+}
+
+//CaseStatement represents CASE Selector OF Branches ELSE Else END,
+//Else is nil without an ELSE or OTHERWISE part
+type CaseStatement struct {
+	Span
+	Selector Expr
+	Branches []CaseBranch
+	Else     []Expr
+}
+
+func (caseSt CaseStatement) ToStr() string {
+	return fmt.Sprint(caseSt)
+}
+
+//CaseBranch represents the case element Labels: Body
+type CaseBranch struct {
+	Span
+	Labels []CaseLabel
+	Body   Expr
+}
+
+func (branch CaseBranch) ToStr() string {
+	return fmt.Sprint(branch)
+}
+
+//CaseLabel represents a case label Low, or the range Low..High,
+//High is nil for a single value
+type CaseLabel struct {
+	Span
+	Low  Expr
+	High Expr
+}
+
+func (label CaseLabel) ToStr() string {
+	return fmt.Sprint(label)
+}
+
 type NoOp struct {
+	Span
 }
 
 func (noop NoOp) ToStr() string {
 	return "noop"
 }
 
+//Program represents the program, Mode is the compiler mode set by
+//a {$mode name} directive, like DELPHI
 type Program struct {
+	Span
 	Block Block
 	Name  string
+	Mode  string
+	// RangeChecks is set by the {$R+} directive, values stored in a variable of a
+	// subrange type are checked against its bounds at runtime
+	RangeChecks bool
 }
 
 func (prog Program) ToStr() string {
@@ -101,6 +229,7 @@ func (prog Program) ToStr() string {
 }
 
 type Block struct {
+	Span
 	Decl     Decl
 	Compound Compound
 }
@@ -110,15 +239,82 @@ func (blk Block) ToStr() string {
 }
 
 type Decl struct {
+	Span
+	ConstDeclList []ConstDecl
+	TypeDeclList  []TypeDecl
 	VarDeclList   []VarDecl
 	ProceDeclList []Procedure
+	FuncDeclList  []Function
 }
 
 func (decl Decl) ToStr() string {
 	return fmt.Sprint(decl)
 }
 
+//Definitions returns the constant and type declarations in the order of the source, each a
+//ConstDecl or a TypeDecl, so that a declaration may use the ones written before it
+func (decl Decl) Definitions() []Expr {
+	defs := make([]Expr, 0, len(decl.ConstDeclList)+len(decl.TypeDeclList))
+	i, j := 0, 0
+	for i < len(decl.ConstDeclList) || j < len(decl.TypeDeclList) {
+		if j == len(decl.TypeDeclList) || i < len(decl.ConstDeclList) && decl.ConstDeclList[i].Start.Offset < decl.TypeDeclList[j].Start.Offset {
+			defs = append(defs, decl.ConstDeclList[i])
+			i++
+			continue
+		}
+		defs = append(defs, decl.TypeDeclList[j])
+		j++
+	}
+	return defs
+}
+
+//Routines returns the procedure and function declarations in the order of the source, each
+//a Procedure or a Function, so that a routine may call the ones written before it
+func (decl Decl) Routines() []Expr {
+	routines := make([]Expr, 0, len(decl.ProceDeclList)+len(decl.FuncDeclList))
+	i, j := 0, 0
+	for i < len(decl.ProceDeclList) || j < len(decl.FuncDeclList) {
+		if j == len(decl.FuncDeclList) || i < len(decl.ProceDeclList) && decl.ProceDeclList[i].Start.Offset < decl.FuncDeclList[j].Start.Offset {
+			routines = append(routines, decl.ProceDeclList[i])
+			i++
+			continue
+		}
+		routines = append(routines, decl.FuncDeclList[j])
+		j++
+	}
+	return routines
+}
+
+//ConstDecl declares a constant, Type is empty for an untyped constant which has the type of its value
+type ConstDecl struct {
+	Span
+	Node  VarNode
+	Type  token.Type
+	Value Expr
+}
+
+func (constDecl ConstDecl) ToStr() string {
+	return fmt.Sprint(constDecl)
+}
+
+//TypeDecl declares a type name, an alias of Type or, when Low is not nil,
+//a subrange of the ordinal type of its bounds or, when Values is not nil,
+//an enumerated type whose values are the constants named by Values in order
+type TypeDecl struct {
+	Span
+	Node   VarNode
+	Type   token.Type
+	Low    Expr
+	High   Expr
+	Values []VarNode
+}
+
+func (typeDecl TypeDecl) ToStr() string {
+	return fmt.Sprint(typeDecl)
+}
+
 type VarDecl struct {
+	Span
 	Node VarNode
 	Type token.Type
 }
@@ -128,10 +324,63 @@ func (vardecl VarDecl) ToStr() string {
 }
 
 type Procedure struct {
-	Name  string
-	Block Block
+	Span
+	Name   string
+	Params []Param
+	Block  Block
 }
 
 func (procedure Procedure) ToStr() string {
 	return fmt.Sprint(procedure)
 }
+
+//Function represents a function declaration, the result is assigned to its name
+type Function struct {
+	Span
+	Name       string
+	Params     []Param
+	ReturnType token.Type
+	Block      Block
+}
+
+func (function Function) ToStr() string {
+	return fmt.Sprint(function)
+}
+
+//FunctionCall represents the call Name(Args) in an expression,
+//a call without arguments is parsed as a VarNode
+type FunctionCall struct {
+	Span
+	Tok  token.Token
+	Name string
+	Args []Expr
+}
+
+func (call FunctionCall) ToStr() string {
+	return fmt.Sprint(call)
+}
+
+//Param represents a formal parameter, Mode is token.VAR for a parameter passed
+//by reference, token.CONST for a read only one and empty for a value parameter
+type Param struct {
+	Span
+	Node VarNode
+	Type token.Type
+	Mode token.Type
+}
+
+func (param Param) ToStr() string {
+	return fmt.Sprint(param)
+}
+
+//ProcedureCall represents the call statement Name(Args)
+type ProcedureCall struct {
+	Span
+	Tok  token.Token
+	Name string
+	Args []Expr
+}
+
+func (call ProcedureCall) ToStr() string {
+	return fmt.Sprint(call)
+}
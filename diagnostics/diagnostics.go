@@ -0,0 +1,146 @@
+package diagnostics
+
+import (
+	"fmt"
+	"pascal_in_go/token"
+)
+
+//Code identifies the kind of a diagnostic, codes are stable and never reused,
+//the letter tells the stage: L lexer, P parser, S semantic analysis and R runtime
+type Code string
+
+const (
+	IllegalCharacter    Code = "L001"
+	InvalidCharCode     Code = "L002"
+	UnterminatedString  Code = "L003"
+	UnterminatedComment Code = "L004"
+
+	UnexpectedToken Code = "P001"
+
+	Undeclared          Code = "S001"
+	DuplicateIdentifier Code = "S002"
+	TypeMismatch        Code = "S003"
+	WrongKind           Code = "S004"
+	ArgumentCount       Code = "S005"
+	InvalidAssignment   Code = "S006"
+	InvalidForVariable  Code = "S007"
+	InvalidCaseLabel    Code = "S008"
+	ReadBeforeAssigned  Code = "S009"
+	UnusedVariable      Code = "S010"
+	UnreadVariable      Code = "S011"
+	InvalidConstant     Code = "S012"
+	OutOfRange          Code = "S013"
+
+	UndefinedRoutine  Code = "R001"
+	DivisionByZero    Code = "R002"
+	UndefinedVariable Code = "R003"
+	RangeCheck        Code = "R004"
+	InvalidOperand    Code = "R005"
+	InvalidArguments  Code = "R006"
+	InvalidValue      Code = "R007"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+//Diagnostic is implemented by the errors of every stage
+type Diagnostic interface {
+	error
+	GetCode() Code
+	GetSeverity() Severity
+	GetMessage() string
+	GetSpan() token.Span
+}
+
+//Info holds what every diagnostic has, the message is written without the position
+type Info struct {
+	Code     Code
+	Severity Severity
+	Message  string
+	Span     token.Span
+}
+
+func (info Info) GetCode() Code {
+	return info.Code
+}
+
+func (info Info) GetSeverity() Severity {
+	return info.Severity
+}
+
+func (info Info) GetMessage() string {
+	return info.Message
+}
+
+func (info Info) GetSpan() token.Span {
+	return info.Span
+}
+
+//Error writes the start position and the message, a warning says so
+func (info Info) Error() string {
+	if info.Severity == Warning {
+		return fmt.Sprintf("%s: warning: %s", info.Span.Start, info.Message)
+	}
+	return fmt.Sprintf("%s: %s", info.Span.Start, info.Message)
+}
+
+//LexError is text the lexer cannot make a token of
+type LexError struct {
+	Info
+}
+
+//SyntaxError is a token the grammar does not allow where it is found, Expected is the
+//token type wanted there, or a word like "expression" when no single token would do
+type SyntaxError struct {
+	Info
+	Expected token.Type
+	Actual   token.Token
+}
+
+//SemanticError is an error or a warning of the semantic analysis
+type SemanticError struct {
+	Info
+}
+
+//RuntimeError stops a running program
+type RuntimeError struct {
+	Info
+}
+
+func NewLexError(code Code, span token.Span, msg string) *LexError {
+	return &LexError{Info{Code: code, Severity: Error, Message: msg, Span: span}}
+}
+
+func NewSyntaxError(expected token.Type, actual token.Token) *SyntaxError {
+	msg := fmt.Sprintf("type not match, cur is %s %q and expected is %s", actual.Type, actual.Literal, expected)
+	span := token.Span{Start: actual.Pos, End: actual.End}
+	return &SyntaxError{
+		Info:     Info{Code: UnexpectedToken, Severity: Error, Message: msg, Span: span},
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+func NewSemanticError(code Code, span token.Span, msg string) *SemanticError {
+	return &SemanticError{Info{Code: code, Severity: Error, Message: msg, Span: span}}
+}
+
+//NewSemanticWarning returns a SemanticError of severity Warning, it does not stop the program
+func NewSemanticWarning(code Code, span token.Span, msg string) *SemanticError {
+	return &SemanticError{Info{Code: code, Severity: Warning, Message: msg, Span: span}}
+}
+
+func NewRuntimeError(code Code, span token.Span, msg string) *RuntimeError {
+	return &RuntimeError{Info{Code: code, Severity: Error, Message: msg, Span: span}}
+}
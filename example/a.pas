@@ -1,3 +1,4 @@
+{ demo program for the interpreter }
 PROGRAM P11;
 VAR
    number : INTEGER;
@@ -7,6 +8,6 @@ VAR
 BEGIN 
    number := 2;
    a := number ;
-   b := 10 * a + 10 * number / 4;
-   y := 20 / 7 + 3.14
+   b := 10 * a + 10 * number div 4;
+   y := 20 / 7 + 3.14 // real division
 END.  
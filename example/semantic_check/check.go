@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"pascal_in_go/diagnostics"
 	"pascal_in_go/lexer"
 	"pascal_in_go/parser"
 	"pascal_in_go/types"
@@ -24,14 +25,20 @@ func main() {
 	fmt.Println("Semantic Analyzing: ")
 	lexer := lexer.NewLexer(text)
 	parser := parser.NewParser(lexer)
-	symboltable := &types.SymbolTable{Symbols: make(map[string]types.Symbol), ErrorList: make([]error, 0)}
+	symboltable := types.NewSymbolTable()
 	symboltable.InitBuiltins()
-	tree := parser.Program()
+	// the checker goes on with the partial tree of a program with syntax errors
+	tree, _ := parser.Program()
 	symboltable.Visit(tree)
 	fmt.Println("-------------------")
 	fmt.Println("Error Reporting : ")
-	errList := symboltable.ErrorList
+	errList := append(parser.Errors(), symboltable.ErrorList...)
 	for _, err := range errList {
+		// a warning says so itself
+		if diag, ok := err.(diagnostics.Diagnostic); ok && diag.GetSeverity() == diagnostics.Warning {
+			fmt.Println(err)
+			continue
+		}
 		fmt.Println("error:  ", err)
 	}
 }
@@ -0,0 +1,90 @@
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+)
+
+//isBuiltin reports whether name is a standard function, see callBuiltin
+func isBuiltin(name string) bool {
+	switch token.Canonical(name) {
+	case "ORD", "SUCC", "PRED", "LOW", "HIGH":
+		return true
+	}
+	return false
+}
+
+//isBuiltinType reports whether name is the name of a builtin type, no variable or declared type has it
+func isBuiltinType(name string) bool {
+	switch token.Type(token.Canonical(name)) {
+	case token.INTEGER, token.REAL, token.CHAR, token.STRING, token.BOOLEAN:
+		return true
+	}
+	return false
+}
+
+//callBuiltin runs the standard function name on its argument, Succ and Pred beyond the
+//values of an enumerated type stop the program with runtime error 201
+func (inp *Interpreter) callBuiltin(name string, span ast.Span, arg ast.Expr) Value {
+	canon := token.Canonical(name)
+	if canon == "LOW" || canon == "HIGH" {
+		low, high := inp.bounds(arg)
+		if canon == "LOW" {
+			return low
+		}
+		return high
+	}
+	value := inp.visit(arg)
+	if canon == "ORD" {
+		return Integer(ordinal(value))
+	}
+	ord := ordinal(value) + 1
+	if canon == "PRED" {
+		ord = ordinal(value) - 1
+	}
+	if v, ok := value.(Enum); ok && (ord < 0 || ord >= int64(len(v.Of.Names))) {
+		msg := fmt.Sprintf("Runtime error 201: range check error, %s(%s) is out of the values of %s", name, value, v.Of.Name)
+		inp.fail(diagnostics.RangeCheck, span, msg)
+	}
+	return fromOrdinal(ord, value)
+}
+
+//bounds returns the least and the greatest value of the type of the argument of Low or High,
+//the builtin or declared type it names, the declared type of the variable it names or the type of its value
+func (inp *Interpreter) bounds(arg ast.Expr) (Value, Value) {
+	var info typeInfo
+	v, isVar := arg.(ast.VarNode)
+	switch {
+	case isVar && isBuiltinType(v.Literal):
+		info = typeInfo{base: token.Type(token.Canonical(v.Literal))}
+	case isVar:
+		ar, key := inp.locate(v.Literal, false)
+		var declared bool
+		if info, declared = ar.Members[key].(typeInfo); !declared {
+			if info, declared = ar.declared[key]; !declared {
+				info = typeInfo{base: ar.Types[key]}
+			}
+		}
+	default:
+		value := inp.visit(arg)
+		info = typeInfo{base: token.Type(value.Type())}
+		if v, ok := value.(Enum); ok {
+			info.enum = v.Of
+		}
+	}
+
+	switch {
+	case info.subrange:
+		return info.low, info.high
+	case info.enum != nil:
+		return Enum{Of: info.enum}, Enum{Ord: int64(len(info.enum.Names) - 1), Of: info.enum}
+	case info.base == token.CHAR:
+		return Char(0), Char(255)
+	case info.base == token.BOOLEAN:
+		return Boolean(false), Boolean(true)
+	}
+	return Integer(math.MinInt64), Integer(math.MaxInt64)
+}
@@ -0,0 +1,117 @@
+package interpreter
+
+import (
+	"fmt"
+	"pascal_in_go/ast"
+	"pascal_in_go/token"
+	"sort"
+	"strings"
+)
+
+//ARType is the kind of routine an activation record runs
+type ARType string
+
+const (
+	PROGRAM   ARType = "PROGRAM"
+	PROCEDURE ARType = "PROCEDURE"
+	FUNCTION  ARType = "FUNCTION"
+)
+
+//resultSlot is the member holding the result of a function, it is no identifier
+const resultSlot = "#result"
+
+//ActivationRecord holds the locals and parameters of a running program, procedure
+//or function by their canonical name, the declared routines are members too.
+//Types holds the builtin or enumerated types of the variables and of the result slot.
+//AccessLink is the static link, the record of the routine the running one is declared in
+type ActivationRecord struct {
+	Name         string
+	Type         ARType
+	NestingLevel int
+	Members      map[string]interface{}
+	Types        map[string]token.Type
+	AccessLink   *ActivationRecord
+	// declared holds the types of the members of a subrange or an enumerated type
+	declared map[string]typeInfo
+}
+
+func NewActivationRecord(name string, arType ARType, level int, link *ActivationRecord) *ActivationRecord {
+	return &ActivationRecord{
+		Name:         name,
+		Type:         arType,
+		NestingLevel: level,
+		Members:      make(map[string]interface{}),
+		Types:        make(map[string]token.Type),
+		AccessLink:   link,
+		declared:     make(map[string]typeInfo),
+	}
+}
+
+func (ar *ActivationRecord) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d: %s %s\n", ar.NestingLevel, ar.Type, ar.Name)
+	keys := make([]string, 0, len(ar.Members))
+	for key := range ar.Members {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch value := ar.Members[key].(type) {
+		case ast.Procedure:
+			fmt.Fprintf(&b, "   %-20s: <procedure>\n", key)
+		case ast.Function:
+			fmt.Fprintf(&b, "   %-20s: <function>\n", key)
+		case typeInfo:
+			fmt.Fprintf(&b, "   %-20s: <type>\n", key)
+		case *reference:
+			fmt.Fprintf(&b, "   %-20s: <var %s of %s>\n", key, value.name, value.record.Name)
+		default:
+			fmt.Fprintf(&b, "   %-20s: %v\n", key, value)
+		}
+	}
+	return b.String()
+}
+
+//CallStack holds the activation records of the running routines, the innermost last
+type CallStack struct {
+	records []*ActivationRecord
+}
+
+func (s *CallStack) Push(ar *ActivationRecord) {
+	s.records = append(s.records, ar)
+}
+
+func (s *CallStack) Pop() *ActivationRecord {
+	if len(s.records) == 0 {
+		return nil
+	}
+	ar := s.records[len(s.records)-1]
+	s.records = s.records[:len(s.records)-1]
+	return ar
+}
+
+//Peek returns the record of the running routine, nil when the stack is empty
+func (s *CallStack) Peek() *ActivationRecord {
+	if len(s.records) == 0 {
+		return nil
+	}
+	return s.records[len(s.records)-1]
+}
+
+//Records returns the records on the stack, the innermost first
+func (s *CallStack) Records() []*ActivationRecord {
+	records := make([]*ActivationRecord, 0, len(s.records))
+	for i := len(s.records) - 1; i >= 0; i-- {
+		records = append(records, s.records[i])
+	}
+	return records
+}
+
+func (s *CallStack) String() string {
+	var b strings.Builder
+	b.WriteString("CALL STACK\n")
+	for _, ar := range s.Records() {
+		b.WriteString(ar.String())
+	}
+	return b.String()
+}
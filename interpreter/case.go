@@ -0,0 +1,73 @@
+package interpreter
+
+import (
+	"pascal_in_go/ast"
+	"sort"
+)
+
+//caseTable maps the selector value of a CASE statement to the index of its branch,
+//single labels are looked up in a map and ranges by binary search
+type caseTable struct {
+	values map[int64]int
+	ranges []caseRange
+}
+
+type caseRange struct {
+	low    int64
+	high   int64
+	branch int
+}
+
+//caseTable returns the table of a CASE statement, built when it runs for the first time
+func (inp *Interpreter) caseTable(t ast.CaseStatement) *caseTable {
+	if table, ok := inp.caseTables[t.Start]; ok {
+		return table
+	}
+
+	table := &caseTable{values: make(map[int64]int)}
+	for i, branch := range t.Branches {
+		for _, label := range branch.Labels {
+			low := ordinal(inp.visit(label.Low))
+			if label.High == nil {
+				if _, ok := table.values[low]; !ok {
+					table.values[low] = i
+				}
+				continue
+			}
+			high := ordinal(inp.visit(label.High))
+			table.ranges = append(table.ranges, caseRange{low: low, high: high, branch: i})
+		}
+	}
+	sort.SliceStable(table.ranges, func(i, j int) bool {
+		return table.ranges[i].low < table.ranges[j].low
+	})
+	inp.caseTables[t.Start] = table
+	return table
+}
+
+//lookup returns the branch of the selector value, ok is false when no label matches
+func (table *caseTable) lookup(value int64) (branch int, ok bool) {
+	if branch, ok = table.values[value]; ok {
+		return branch, true
+	}
+	// labels do not overlap, so only the last range starting at or before value may hold it
+	i := sort.Search(len(table.ranges), func(i int) bool {
+		return table.ranges[i].low > value
+	}) - 1
+	if i >= 0 && table.ranges[i].high >= value {
+		return table.ranges[i].branch, true
+	}
+	return 0, false
+}
+
+//visitCase runs the branch whose labels hold the selector, or the ELSE part when none does
+func (inp *Interpreter) visitCase(t ast.CaseStatement) {
+	value := ordinal(inp.visit(t.Selector))
+	if branch, ok := inp.caseTable(t).lookup(value); ok {
+		inp.visit(t.Branches[branch].Body)
+		return
+	}
+	for _, st := range t.Else {
+		inp.visit(st)
+	}
+}
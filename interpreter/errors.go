@@ -0,0 +1,39 @@
+package interpreter
+
+import (
+	"fmt"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+)
+
+//fail stops the running program with a *diagnostics.RuntimeError, Expr recovers it
+func (inp *Interpreter) fail(code diagnostics.Code, span ast.Span, msg string) {
+	panic(diagnostics.NewRuntimeError(code, span, msg))
+}
+
+//divisionByZero stops the program at the operator dividing by zero, as Turbo Pascal
+//does with runtime error 200
+func (inp *Interpreter) divisionByZero(t ast.BinNode) {
+	span := ast.Span{Start: t.Tok.Pos, End: t.Tok.End}
+	inp.fail(diagnostics.DivisionByZero, span, "Runtime error 200: division by zero")
+}
+
+//invalidOperands stops the program at an operator not applicable to the values of its operands
+func (inp *Interpreter) invalidOperands(t ast.BinNode, left Value, right Value) {
+	span := ast.Span{Start: t.Tok.Pos, End: t.Tok.End}
+	msg := fmt.Sprintf("operator %s not applicable to %s and %s", t.Tok.Literal, left.Type(), right.Type())
+	inp.fail(diagnostics.InvalidOperand, span, msg)
+}
+
+//recoverError turns the runtime error stopping the program into err, any other panic goes on
+func recoverError(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	runtimeErr, ok := r.(*diagnostics.RuntimeError)
+	if !ok {
+		panic(r)
+	}
+	*err = runtimeErr
+}
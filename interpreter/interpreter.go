@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
 	"pascal_in_go/parser"
 	"pascal_in_go/token"
 	"strconv"
@@ -12,23 +13,117 @@ import (
 //Interpreter represents the interpreter struct
 type Interpreter struct {
 	Parser *parser.Parser
-	VarMap map[string]interface{}
+	// VarMap holds the globals with a value by their declared spelling once the program ran
+	VarMap map[string]Value
+	// TypeMap holds the declared type of each global in VarMap by its declared spelling
+	TypeMap map[string]token.Type
+	// Stack holds the activation records of the program and the running routines
+	Stack *CallStack
+	// names maps the canonical name of a global to its declared spelling
+	names map[string]string
+	// caseTables holds the dispatch table of each CASE statement by its position
+	caseTables map[token.Position]*caseTable
+	// mode is the compiler mode of the program, see ast.Program
+	mode string
+	// rangeChecks is set by the {$R+} directive of the program, see ast.Program
+	rangeChecks bool
+	// Strict makes every variable and function result start undefined, reading one
+	// before it is assigned is a runtime error instead of giving the zero value
+	Strict bool
+}
+
+//reference is the value of a VAR parameter, it refers to the variable name in record
+type reference struct {
+	record *ActivationRecord
+	name   string
 }
 
 func NewInterpreter(parser *parser.Parser) *Interpreter {
-	return &Interpreter{Parser: parser, VarMap: make(map[string]interface{})}
+	return &Interpreter{
+		Parser:     parser,
+		VarMap:     make(map[string]Value),
+		TypeMap:    make(map[string]token.Type),
+		Stack:      &CallStack{},
+		names:      make(map[string]string),
+		caseTables: make(map[token.Position]*caseTable),
+	}
+}
+
+//resultVar reports whether functions have the implicit Result variable
+func (inp *Interpreter) resultVar() bool {
+	return inp.mode == "DELPHI" || inp.mode == "OBJFPC"
+}
+
+//locate returns the record holding name and its key there, walking the access links
+//from the running routine and following the references of VAR parameters. A variable
+//belongs to the record declaring it even before it has a value, a name not found is
+//a global, the name of a function assigned in its body or Result stands for the result
+//slot of the function
+func (inp *Interpreter) locate(name string, assign bool) (*ActivationRecord, string) {
+	canon := token.Canonical(name)
+	var program *ActivationRecord
+	for ar := inp.Stack.Peek(); ar != nil; ar = ar.AccessLink {
+		if value, ok := ar.Members[canon]; ok {
+			if ref, isRef := value.(*reference); isRef {
+				return ref.record, ref.name
+			}
+			return ar, canon
+		}
+		if _, declared := ar.Types[canon]; declared {
+			return ar, canon
+		}
+		if ar.Type == FUNCTION && (canon == "RESULT" && inp.resultVar() || assign && canon == token.Canonical(ar.Name)) {
+			return ar, resultSlot
+		}
+		program = ar
+	}
+	return program, canon
+}
+
+//store assigns a variable, converting the value to the declared type, a value not of
+//that type stops the program. Span is where the value comes from for the range checks
+func (inp *Interpreter) store(name string, value Value, span ast.Span) {
+	ar, key := inp.locate(name, true)
+	if ar == nil {
+		return
+	}
+	typeName := ar.Types[key]
+	value = convert(value, typeName)
+	if typeName != "" && value.Type() != string(typeName) {
+		msg := fmt.Sprintf("cannot assign %s to %s variable %s", value.Type(), typeName, name)
+		inp.fail(diagnostics.InvalidValue, span, msg)
+	}
+	inp.checkRange(ar, key, value, span)
+	ar.Members[key] = value
+}
+
+//undefine forgets the value of a variable
+func (inp *Interpreter) undefine(name string) {
+	ar, key := inp.locate(name, true)
+	if ar == nil {
+		return
+	}
+	ar.Members[key] = nil
 }
-func (inp *Interpreter) Expr() map[string]interface{} {
-	astTree := inp.Parser.Program()
+
+//Expr parses and runs the program and returns its globals, or the syntax errors
+//stopping the parser or the runtime error stopping the program
+func (inp *Interpreter) Expr() (vars map[string]Value, err error) {
+	astTree, err := inp.Parser.Program()
+	if err != nil {
+		return nil, err
+	}
 	log.Printf("tree is %+v\n", astTree)
 	log.Println("-------------------")
+	defer recoverError(&err)
 	inp.visit(astTree)
-	return inp.VarMap
+	return inp.VarMap, nil
 }
 
-func (inp *Interpreter) visit(astTree ast.Expr) float64 {
+//visit runs a statement and returns nil, or evaluates an expression and returns its value
+func (inp *Interpreter) visit(astTree ast.Expr) Value {
 	if astTree == nil {
-		return 0
+		return nil
 	}
 
 	switch t := astTree.(type) {
@@ -44,68 +139,278 @@ func (inp *Interpreter) visit(astTree ast.Expr) float64 {
 		inp.visitAssignment(t)
 	case ast.Statement:
 		inp.visitStatement(t)
+	case ast.IfStatement:
+		inp.visitIf(t)
+	case ast.WhileStatement:
+		inp.visitWhile(t)
+	case ast.RepeatStatement:
+		inp.visitRepeat(t)
+	case ast.ForStatement:
+		inp.visitFor(t)
+	case ast.CaseStatement:
+		inp.visitCase(t)
+	case ast.ProcedureCall:
+		inp.visitProcedureCall(t)
+	case ast.FunctionCall:
+		return inp.call(t.Name, t.Span, t.Args)
 	case ast.BinNode:
-		res := inp.visitBinNode(t)
-		return res
+		return inp.visitBinNode(t)
 	case ast.Unary:
-		if t.Op == token.PLUS {
-			return inp.visit(t.Expr)
-		}
-		if t.Op == token.MINUS {
-			return -inp.visit(t.Expr)
-		}
-
+		return inp.visitUnary(t)
 	case ast.NumNode:
-		num, _ := strconv.ParseFloat(t.Tok.Literal, 64)
-		return num
-
+		return visitNum(t)
+	case ast.StringNode:
+		if t.Tok.Type == token.CHAR_CONST {
+			return Char(t.Value[0])
+		}
+		return String(t.Value)
+	case ast.BoolNode:
+		return Boolean(t.Value)
 	case ast.VarNode:
 		return inp.visitVar(t)
 	default:
 		fmt.Println("no match", t)
 	}
-	return 0
+	return nil
 }
 
-func (inp *Interpreter) visitBinNode(t ast.BinNode) float64 {
-	if t.Tok.Type == token.PLUS {
-		left := inp.visit(t.Left)
-		right := inp.visit(t.Right)
-		return left + right
+//visitNum returns an INTEGER literal as an Integer, one too large for it as a Real
+func visitNum(t ast.NumNode) Value {
+	if t.Tok.Type == token.INTEGER {
+		if num, err := strconv.ParseInt(t.Tok.Literal, 10, 64); err == nil {
+			return Integer(num)
+		}
+	}
+	num, _ := strconv.ParseFloat(t.Tok.Literal, 64)
+	return Real(num)
+}
+
+//visitBinNode applies an operator, + - and * give an Integer when both operands are
+//Integer and a Real otherwise, / always gives a Real and DIV and MOD an Integer.
+//Operands the operator is not applicable to stop the program
+func (inp *Interpreter) visitBinNode(t ast.BinNode) Value {
+	switch t.Tok.Type {
+	case token.AND, token.OR, token.XOR:
+		return inp.visitLogical(t)
+	case token.EQ, token.NE, token.LT, token.LE, token.GT, token.GE:
+		return inp.visitRelational(t)
 	}
 
-	if t.Tok.Type == token.MINUS {
-		return inp.visit(t.Left) - inp.visit(t.Right)
+	left := inp.visit(t.Left)
+	right := inp.visit(t.Right)
+	if t.Tok.Type == token.PLUS && isText(left) && isText(right) {
+		return String(left.String() + right.String())
+	}
+	if !isNumber(left) || !isNumber(right) {
+		inp.invalidOperands(t, left, right)
 	}
 
-	if t.Tok.Type == token.MUL {
-		return inp.visit(t.Left) * inp.visit(t.Right)
+	if t.Tok.Type == token.REAL_DIV {
+		divisor := toReal(right)
+		if divisor == 0 {
+			inp.divisionByZero(t)
+		}
+		return toReal(left) / divisor
 	}
 
-	if t.Tok.Type == token.DIV {
-		temp := inp.visit(t.Right)
-		if temp != 0 {
-			return inp.visit(t.Left) / temp
+	l, lok := left.(Integer)
+	r, rok := right.(Integer)
+	if !lok || !rok {
+		switch t.Tok.Type {
+		case token.PLUS:
+			return toReal(left) + toReal(right)
+		case token.MINUS:
+			return toReal(left) - toReal(right)
+		case token.MUL:
+			return toReal(left) * toReal(right)
 		}
-		return parser.INF
+		// DIV and MOD take integers only
+		inp.invalidOperands(t, left, right)
 	}
 
-	return 0
+	switch t.Tok.Type {
+	case token.PLUS:
+		return l + r
+	case token.MINUS:
+		return l - r
+	case token.MUL:
+		return l * r
+	}
+	// DIV truncates toward zero and MOD takes the sign of the dividend, as in Turbo and Free Pascal
+	if r == 0 {
+		inp.divisionByZero(t)
+	}
+	if t.Tok.Type == token.MOD {
+		return l % r
+	}
+	return l / r
+}
+
+//visitUnary applies a sign to a number, NOT negates a boolean or the bits of an integer
+func (inp *Interpreter) visitUnary(t ast.Unary) Value {
+	value := inp.visit(t.Expr)
+	switch v := value.(type) {
+	case Integer:
+		if t.Op == token.MINUS {
+			return -v
+		}
+		if t.Op == token.NOT {
+			return ^v
+		}
+	case Real:
+		if t.Op == token.MINUS {
+			return -v
+		}
+	case Boolean:
+		if t.Op == token.NOT {
+			return !v
+		}
+	}
+	return value
 }
 
+//visitProgram runs the program in its activation record, the global variables with a value are left in VarMap
 func (inp *Interpreter) visitProgram(t ast.Program) {
+	inp.mode = t.Mode
+	inp.rangeChecks = t.RangeChecks
+	program := NewActivationRecord(t.Name, PROGRAM, 1, nil)
+	inp.Stack.Push(program)
 	inp.visitBlock(t.Block)
+	inp.Stack.Pop()
+
+	for canon, member := range program.Members {
+		value, ok := member.(Value)
+		if !ok {
+			continue
+		}
+		// constants are members too, only the variables are named
+		if name, ok := inp.names[canon]; ok {
+			inp.VarMap[name] = value
+			inp.TypeMap[name] = program.Types[canon]
+		}
+	}
 }
 
 func (inp *Interpreter) visitBlock(t ast.Block) {
+	for _, def := range t.Decl.Definitions() {
+		switch d := def.(type) {
+		case ast.ConstDecl:
+			inp.visitConstDecl(d)
+		case ast.TypeDecl:
+			inp.visitTypeDecl(d)
+		}
+	}
 	for _, vardecl := range t.Decl.VarDeclList {
 		inp.visitVarDecl(vardecl)
 	}
+	ar := inp.Stack.Peek()
+	for _, procedure := range t.Decl.ProceDeclList {
+		ar.Members[token.Canonical(procedure.Name)] = procedure
+	}
+	for _, function := range t.Decl.FuncDeclList {
+		ar.Members[token.Canonical(function.Name)] = function
+	}
 	inp.visitCompound(t.Compound)
 }
 
-func (inp *Interpreter) visitVarDecl(t ast.VarDecl) {}
-func (inp *Interpreter) visitDecl(t ast.Decl)       {}
+//visitConstDecl stores the value of a constant in the running routine, a typed constant
+//holds it converted to its type
+func (inp *Interpreter) visitConstDecl(t ast.ConstDecl) {
+	ar := inp.Stack.Peek()
+	canon := token.Canonical(t.Node.Literal)
+	value := inp.visit(t.Value)
+	if t.Type != "" {
+		value = convert(value, inp.resolve(ar, t.Type).base)
+	}
+	ar.Types[canon] = token.Type(value.Type())
+	ar.Members[canon] = value
+}
+
+//visitVarDecl declares a variable of the running routine, a local starts with the zero value
+//of its type unless the interpreter is strict and a global has no value until it is assigned
+func (inp *Interpreter) visitVarDecl(t ast.VarDecl) {
+	ar := inp.Stack.Peek()
+	canon := token.Canonical(t.Node.Literal)
+	ar.declare(canon, inp.resolve(ar, t.Type))
+	if ar.Type == PROGRAM {
+		inp.names[canon] = t.Node.Literal
+		return
+	}
+	if !inp.Strict {
+		ar.Members[canon] = ar.zero(canon)
+	}
+}
+
+//visitProcedureCall runs the procedure, a function called as a statement
+//runs the same way and its result is dropped
+func (inp *Interpreter) visitProcedureCall(t ast.ProcedureCall) {
+	inp.call(t.Name, t.Span, t.Args)
+}
+
+//call runs the procedure or function name in a new activation record whose access link
+//is the record the routine is declared in, and returns the result of a function
+func (inp *Interpreter) call(name string, span ast.Span, args []ast.Expr) Value {
+	declaring, key := inp.locate(name, false)
+	var callee *ActivationRecord
+	var block ast.Block
+	switch routine := declaring.Members[key].(type) {
+	case ast.Procedure:
+		callee = NewActivationRecord(routine.Name, PROCEDURE, declaring.NestingLevel+1, declaring)
+		inp.bindArguments(callee, declaring, routine.Params, args, span)
+		block = routine.Block
+	case ast.Function:
+		callee = NewActivationRecord(routine.Name, FUNCTION, declaring.NestingLevel+1, declaring)
+		inp.bindArguments(callee, declaring, routine.Params, args, span)
+		callee.declare(resultSlot, inp.resolve(declaring, routine.ReturnType))
+		if !inp.Strict {
+			callee.Members[resultSlot] = callee.zero(resultSlot)
+		}
+		block = routine.Block
+	default:
+		if isBuiltin(name) && len(args) == 1 {
+			return inp.callBuiltin(name, span, args[0])
+		}
+		inp.fail(diagnostics.UndefinedRoutine, span, fmt.Sprintf("routine %s undeclared", name))
+	}
+
+	inp.Stack.Push(callee)
+	inp.visitBlock(block)
+	inp.Stack.Pop()
+	result, ok := callee.Members[resultSlot].(Value)
+	if !ok && callee.Type == FUNCTION {
+		inp.fail(diagnostics.UndefinedVariable, span, fmt.Sprintf("result of function %s is undefined", name))
+	}
+	return result
+}
+
+//bindArguments binds the arguments to the parameters in the record of the callee, whose types
+//are those of the declaring record, a VAR parameter refers to the variable passed by the caller.
+//A wrong number of arguments or a VAR argument which is no variable stops the program at span
+func (inp *Interpreter) bindArguments(callee *ActivationRecord, declaring *ActivationRecord, params []ast.Param, args []ast.Expr, span ast.Span) {
+	if len(args) != len(params) {
+		msg := fmt.Sprintf("wrong number of arguments to %s, got %d and expected %d", callee.Name, len(args), len(params))
+		inp.fail(diagnostics.InvalidArguments, span, msg)
+	}
+	for i, param := range params {
+		name := token.Canonical(param.Node.Literal)
+		if param.Mode == token.VAR {
+			variable, ok := args[i].(ast.VarNode)
+			if !ok {
+				msg := fmt.Sprintf("argument for VAR parameter %s of %s must be a variable", param.Node.Literal, callee.Name)
+				inp.fail(diagnostics.InvalidArguments, span, msg)
+			}
+			ar, key := inp.locate(variable.Literal, true)
+			callee.Members[name] = &reference{record: ar, name: key}
+			continue
+		}
+		callee.declare(name, inp.resolve(declaring, param.Type))
+		value := convert(inp.visit(args[i]), callee.Types[name])
+		inp.checkRange(callee, name, value, args[i].GetSpan())
+		callee.Members[name] = value
+	}
+}
+
+func (inp *Interpreter) visitDecl(t ast.Decl) {}
 
 func (inp *Interpreter) visitCompound(t ast.Compound) {
 	for _, child := range t.Children {
@@ -124,30 +429,164 @@ func (inp *Interpreter) visitStatement(t ast.Statement) {
 		inp.visitAssignment(node)
 	case ast.Compound:
 		inp.visitCompound(node)
+	case ast.IfStatement:
+		inp.visitIf(node)
+	case ast.WhileStatement:
+		inp.visitWhile(node)
+	case ast.RepeatStatement:
+		inp.visitRepeat(node)
+	case ast.ForStatement:
+		inp.visitFor(node)
+	case ast.CaseStatement:
+		inp.visitCase(node)
+	case ast.ProcedureCall:
+		inp.visitProcedureCall(node)
 	case ast.NoOp:
 		return
 	}
 }
+
+func (inp *Interpreter) visitIf(t ast.IfStatement) {
+	if inp.condition(t.Cond) {
+		inp.visit(t.Then)
+	} else if t.Else != nil {
+		inp.visit(t.Else)
+	}
+}
+func (inp *Interpreter) visitWhile(t ast.WhileStatement) {
+	for {
+		if !inp.condition(t.Cond) {
+			return
+		}
+		inp.visit(t.Body)
+	}
+}
+
+//visitRepeat runs the body at least once, until the condition holds
+func (inp *Interpreter) visitRepeat(t ast.RepeatStatement) {
+	for {
+		for _, st := range t.Body {
+			inp.visit(st)
+		}
+		if inp.condition(t.Cond) {
+			return
+		}
+	}
+}
+
+//condition evaluates the condition of a statement, a value other than a Boolean stops the program
+func (inp *Interpreter) condition(cond ast.Expr) Boolean {
+	value := inp.visit(cond)
+	b, ok := value.(Boolean)
+	if !ok {
+		msg := fmt.Sprintf("condition must be BOOLEAN, got %s", value.Type())
+		inp.fail(diagnostics.InvalidOperand, cond.GetSpan(), msg)
+	}
+	return b
+}
+
+//visitFor evaluates the bounds once, then assigns every ordinal value between them
+//to the control variable, which is undefined after the loop
+func (inp *Interpreter) visitFor(t ast.ForStatement) {
+	name := t.Var.Literal
+	start := inp.visit(t.Start)
+	first := ordinal(start)
+	last := ordinal(inp.visit(t.Stop))
+	step := int64(1)
+	if t.Down {
+		step = -1
+	}
+
+	if (!t.Down && first <= last) || (t.Down && first >= last) {
+		for i := first; ; i += step {
+			inp.store(name, fromOrdinal(i, start), t.Var.Span)
+			inp.visit(t.Body)
+			if i == last {
+				break
+			}
+		}
+	}
+	inp.undefine(name)
+}
+
 func (inp *Interpreter) visitAssignment(st ast.AssignStatement) {
 	varName := st.Left.Literal
 	rValue := inp.visit(st.Right)
-	inp.VarMap[varName] = rValue
+	inp.store(varName, rValue, st.Right.GetSpan())
 }
 
-func (inp *Interpreter) visitVar(node ast.VarNode) float64 {
-	name := node.Literal
-	value, ok := inp.VarMap[name]
-	if !ok {
-		return 0
-	}
-	switch t := value.(type) {
-	case string:
-		val, _ := strconv.ParseFloat(t, 64)
-		return val
-	case float64:
-		return t
+//visitLogical evaluates AND, OR and XOR, boolean AND and OR are short-circuited
+//like turbo pascal does, integer operands are combined bitwise. Operands of other
+//types stop the program
+func (inp *Interpreter) visitLogical(t ast.BinNode) Value {
+	left := inp.visit(t.Left)
+	if l, ok := left.(Boolean); ok {
+		if t.Tok.Type == token.AND && !l {
+			return Boolean(false)
+		}
+		if t.Tok.Type == token.OR && l {
+			return Boolean(true)
+		}
+		right := inp.visit(t.Right)
+		r, ok := right.(Boolean)
+		if !ok {
+			inp.invalidOperands(t, left, right)
+		}
+		if t.Tok.Type == token.XOR {
+			return Boolean(l != r)
+		}
+		return r
+	}
+
+	right := inp.visit(t.Right)
+	l, isInt := left.(Integer)
+	r, ok := right.(Integer)
+	if !isInt || !ok {
+		inp.invalidOperands(t, left, right)
+	}
+	switch t.Tok.Type {
+	case token.AND:
+		return l & r
+	case token.OR:
+		return l | r
+	default:
+		return l ^ r
+	}
+}
+
+func (inp *Interpreter) visitRelational(t ast.BinNode) Boolean {
+	res := compare(inp.visit(t.Left), inp.visit(t.Right))
+	switch t.Tok.Type {
+	case token.EQ:
+		return res == 0
+	case token.NE:
+		return res != 0
+	case token.LT:
+		return res < 0
+	case token.LE:
+		return res <= 0
+	case token.GT:
+		return res > 0
 	default:
-		return 0
+		return res >= 0
 	}
+}
 
+//visitVar returns the value of a variable, the zero value of its type when it has none
+//or a runtime error in strict mode, the name of a function stands for a call without arguments
+func (inp *Interpreter) visitVar(node ast.VarNode) Value {
+	ar, key := inp.locate(node.Literal, false)
+	if ar == nil {
+		return Integer(0)
+	}
+	switch value := ar.Members[key].(type) {
+	case ast.Function:
+		return inp.call(node.Literal, node.Span, nil)
+	case Value:
+		return value
+	}
+	if inp.Strict {
+		inp.fail(diagnostics.UndefinedVariable, node.Span, fmt.Sprintf("variable %s is undefined", node.Literal))
+	}
+	return ar.zero(key)
 }
@@ -0,0 +1,844 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/lexer"
+	"pascal_in_go/parser"
+	"pascal_in_go/token"
+	"strings"
+	"testing"
+)
+
+func run(text string) map[string]Value {
+	lexer := lexer.NewLexer(text)
+	parser := parser.NewParser(lexer)
+	inp := NewInterpreter(parser)
+	result, err := inp.Expr()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+//expectValues runs text and checks the globals named in expect have the expected values
+func expectValues(t *testing.T, text string, expect map[string]Value) map[string]Value {
+	result := run(text)
+	checkValues(t, text, result, expect)
+	return result
+}
+
+//checkValues checks the globals named in expect have the expected values in result
+func checkValues(t *testing.T, text string, result map[string]Value, expect map[string]Value) {
+	for name, want := range expect {
+		if result[name] != want {
+			t.Errorf("%s is %+v; expected  %+v, text is %s\n ", name, result[name], want, text)
+		}
+	}
+}
+
+func TestCaseInsensitiveNames(t *testing.T) {
+	var (
+		text = `program Case1;
+var Number, other : integer;
+Begin
+  number := 3;
+  OTHER := NUMBER * 2
+end.`
+		expect = map[string]Value{"Number": Integer(3), "other": Integer(6)}
+	)
+	result := expectValues(t, text, expect)
+	if len(result) != len(expect) {
+		t.Errorf("result is %+v; expected  %+v\n ", result, expect)
+	}
+}
+
+func TestStringAssignment(t *testing.T) {
+	var (
+		text = `program Strings;
+var s, t : string;
+    c : char;
+begin
+  s := 'It''s';
+  c := #65;
+  t := s + ' ' + c + #33
+end.`
+		expect = map[string]Value{"s": String("It's"), "c": Char('A'), "t": String("It's A!")}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestBooleanExpressions(t *testing.T) {
+	var (
+		text = `program Bools;
+var a, b, c, d : boolean;
+    x : integer;
+begin
+  x := 5;
+  a := x > 3;
+  b := not a or (x = 5) and false;
+  c := (x - 1 <= 2 * 2) xor true;
+  d := 'abc' < 'abd'
+end.`
+		expect = map[string]Value{"a": Boolean(true), "b": Boolean(false), "c": Boolean(false), "d": Boolean(true)}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestIfStatement(t *testing.T) {
+	var (
+		text = `program Branch;
+var x, a, b : integer;
+begin
+  x := 5;
+  if x > 3 then a := 1 else a := 2;
+  if x > 3 then
+    if x > 10 then b := 1
+    else b := 2
+end.`
+		expect = map[string]Value{"a": Integer(1), "b": Integer(2)}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestLoops(t *testing.T) {
+	var (
+		text = `program Loops;
+var i, sum, n, count : integer;
+begin
+  i := 1;
+  sum := 0;
+  while i <= 10 do
+  begin
+    sum := sum + i;
+    i := i + 1
+  end;
+  while false do ;
+  n := 0;
+  repeat
+    n := n + 1;
+  until n >= 3;
+  count := 0;
+  repeat count := count + 1 until true
+end.`
+		expect = map[string]Value{"i": Integer(11), "sum": Integer(55), "n": Integer(3), "count": Integer(1)}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestForLoop(t *testing.T) {
+	var (
+		text = `program Counted;
+var i, n, sum, down, empty : integer;
+    c : char;
+    s : string;
+begin
+  n := 3;
+  sum := 0;
+  for i := 1 to n do
+  begin
+    sum := sum + i;
+    n := 10
+  end;
+  down := 0;
+  for i := 5 downto 1 do down := down * 10 + i;
+  empty := 0;
+  for i := 2 to 1 do empty := 1;
+  s := '';
+  for c := 'a' to 'e' do s := s + c
+end.`
+		expect = map[string]Value{"sum": Integer(6), "down": Integer(54321), "empty": Integer(0), "s": String("abcde")}
+	)
+	result := expectValues(t, text, expect)
+	if _, ok := result["i"]; ok {
+		t.Errorf("i is %+v; expected undefined after the loop\n ", result["i"])
+	}
+}
+
+func TestCaseStatement(t *testing.T) {
+	var (
+		text = `program Cases;
+var i, small, big, other : integer;
+    c : char;
+    kind : string;
+begin
+  small := 0; big := 0; other := 0;
+  for i := 0 to 20 do
+    case i of
+      1, 3: small := small + 1;
+      5..9, 15..17: big := big + 1;
+      20: ;
+    otherwise
+      other := other + 1
+    end;
+  c := 'q';
+  case c of
+    'a'..'m': kind := 'first';
+    'n'..'z': kind := 'second'
+  end
+end.`
+		expect = map[string]Value{"small": Integer(2), "big": Integer(8), "other": Integer(10), "kind": String("second")}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestProcedureCall(t *testing.T) {
+	var (
+		text = `program Calls;
+var a, b, x, total : integer;
+    msg : string;
+
+procedure Swap(var p, q : integer);
+var tmp : integer;
+begin
+  tmp := p;
+  p := q;
+  q := tmp
+end;
+
+procedure Add(n : integer; const step : integer; var acc : integer);
+begin
+  n := n + step;
+  acc := acc + n
+end;
+
+procedure Greet;
+begin
+  msg := 'hi'
+end;
+
+begin
+  a := 1;
+  b := 2;
+  Swap(a, b);
+  x := 10;
+  total := 0;
+  Add(x, 5, total);
+  Add(x, 1, total);
+  greet
+end.`
+		expect = map[string]Value{"a": Integer(2), "b": Integer(1), "x": Integer(10), "total": Integer(26), "msg": String("hi")}
+	)
+	result := expectValues(t, text, expect)
+	if len(result) != len(expect) {
+		t.Errorf("result is %+v; expected  %+v\n ", result, expect)
+	}
+}
+
+func TestFunctionCall(t *testing.T) {
+	var (
+		text = `program Funcs;
+var f, g, s : integer;
+
+function Fact(n : integer) : integer;
+begin
+  if n <= 1 then
+    Fact := 1
+  else
+    Fact := n * Fact(n - 1)
+end;
+
+function Fib(n : integer) : integer;
+var a, b : integer;
+begin
+  if n < 2 then
+    Fib := n
+  else
+  begin
+    a := Fib(n - 1);
+    b := Fib(n - 2);
+    Fib := a + b
+  end
+end;
+
+function Seven : integer;
+begin
+  Seven := 7
+end;
+
+begin
+  f := Fact(5);
+  g := Fib(10);
+  s := Seven + 1
+end.`
+		expect = map[string]Value{"f": Integer(120), "g": Integer(55), "s": Integer(8)}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestFunctionResult(t *testing.T) {
+	var (
+		text = `{$mode delphi}
+program Results;
+var x : integer;
+
+function Twice(n : integer) : integer;
+begin
+  Result := n;
+  Result := Result + n
+end;
+
+begin
+  x := Twice(21)
+end.`
+		expect = map[string]Value{"x": Integer(42)}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestNestedRoutines(t *testing.T) {
+	var (
+		text = `program Nest;
+var total, x, s, d : integer;
+
+procedure Outer(n : integer);
+var acc, x : integer;
+  procedure Inner(k : integer);
+  begin
+    acc := acc + k * n
+  end;
+begin
+  x := 5;
+  acc := 0;
+  Inner(1);
+  Inner(2);
+  total := acc + x
+end;
+
+function Sum(n : integer) : integer;
+var s : integer;
+  procedure AddN;
+  begin
+    s := s + n
+  end;
+begin
+  s := 0;
+  if n > 0 then
+    s := Sum(n - 1);
+  AddN;
+  Sum := s
+end;
+
+function Double(n : integer) : integer;
+  procedure SetResult;
+  begin
+    Double := n * 2
+  end;
+begin
+  SetResult
+end;
+
+begin
+  x := 1;
+  Outer(10);
+  s := Sum(4);
+  d := Double(21)
+end.`
+		expect = map[string]Value{"total": Integer(35), "x": Integer(1), "s": Integer(10), "d": Integer(42)}
+	)
+	result := expectValues(t, text, expect)
+	if len(result) != len(expect) {
+		t.Errorf("result is %+v; expected  %+v\n ", result, expect)
+	}
+}
+
+func TestCallStack(t *testing.T) {
+	stack := &CallStack{}
+	program := NewActivationRecord("Main", PROGRAM, 1, nil)
+	program.Members["X"] = Integer(1)
+	stack.Push(program)
+	proc := NewActivationRecord("Alpha", PROCEDURE, 2, program)
+	proc.Members["A"] = &reference{record: program, name: "X"}
+	stack.Push(proc)
+
+	if stack.Peek() != proc {
+		t.Errorf("top is %+v; expected  %+v\n ", stack.Peek(), proc)
+	}
+	records := stack.Records()
+	if len(records) != 2 || records[0] != proc || records[1] != program {
+		t.Errorf("records are %+v; expected Alpha then Main\n ", records)
+	}
+	expect := "CALL STACK\n" +
+		"2: PROCEDURE Alpha\n" +
+		"   A                   : <var X of Main>\n" +
+		"1: PROGRAM Main\n" +
+		"   X                   : 1\n"
+	if stack.String() != expect {
+		t.Errorf("stack is %q; expected  %q\n ", stack.String(), expect)
+	}
+	if stack.Pop() != proc || stack.Pop() != program || stack.Pop() != nil {
+		t.Errorf("pop does not return the records innermost first\n ")
+	}
+}
+
+func TestDivision(t *testing.T) {
+	var (
+		text = `program Division;
+var a, b, c, d, e, f : integer;
+    r, s : real;
+begin
+  a := 7 div 2;
+  b := -7 div 2;
+  c := 7 mod 3;
+  d := -7 mod 3;
+  e := 7 mod -3;
+  f := 17 DIV 5 MOD 2;
+  r := 7 / 2;
+  s := 8 / 4
+end.`
+		expect = map[string]Value{"a": Integer(3), "b": Integer(-3), "c": Integer(1), "d": Integer(-1), "e": Integer(1), "f": Integer(1), "r": Real(3.5), "s": Real(2)}
+	)
+	expectValues(t, text, expect)
+}
+
+func TestTypedValues(t *testing.T) {
+	var (
+		text = `program Values;
+var big, i : integer;
+    r, half, whole : real;
+    s : string;
+
+function Area(w : real; h : integer) : real;
+begin
+  Area := w * h
+end;
+
+begin
+  big := 9007199254740992 + 1;
+  i := 7;
+  r := i;
+  half := i / 2 + 1;
+  whole := Area(2, 3);
+  s := 'x'
+end.`
+		expect = map[string]Value{
+			"big":   Integer(9007199254740993),
+			"i":     Integer(7),
+			"r":     Real(7),
+			"half":  Real(4.5),
+			"whole": Real(6),
+			"s":     String("x"),
+		}
+	)
+	inp := NewInterpreter(parser.NewParser(lexer.NewLexer(text)))
+	result, err := inp.Expr()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none, text is %s\n ", err, text)
+	}
+	checkValues(t, text, result, expect)
+	// the globals have their declared types
+	for name := range expect {
+		if inp.TypeMap[name] != token.Type(result[name].Type()) {
+			t.Errorf("type of %s is %s; expected  %s\n ", name, inp.TypeMap[name], result[name].Type())
+		}
+	}
+}
+
+func TestValueString(t *testing.T) {
+	expect := []struct {
+		value Value
+		want  string
+	}{
+		{Integer(-12), "-12"},
+		{Real(2), "2.0"},
+		{Real(0.25), "0.25"},
+		{Boolean(true), "TRUE"},
+		{Char('a'), "a"},
+		{String("abc"), "abc"},
+	}
+	for _, e := range expect {
+		if e.value.String() != e.want {
+			t.Errorf("%#v is written %q; expected  %q\n ", e.value, e.value.String(), e.want)
+		}
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	tests := []struct {
+		text string
+		code diagnostics.Code
+		want string
+	}{
+		{`program Missing;
+var x : integer;
+begin
+  x := 1;
+  Nothing(x);
+  x := 2
+end.`, diagnostics.UndefinedRoutine, "5:3: routine Nothing undeclared"},
+		{`program DivZero;
+var x, y : integer;
+begin
+  x := 1;
+  x := x div y;
+  x := 2
+end.`, diagnostics.DivisionByZero, "5:10: Runtime error 200: division by zero"},
+		{`program ModZero;
+var x : integer;
+function Zero : integer;
+begin
+  Zero := 0
+end;
+begin
+  x := 1;
+  x := 7 mod Zero;
+  x := 2
+end.`, diagnostics.DivisionByZero, "9:10: Runtime error 200: division by zero"},
+		{`program RealZero;
+var x : integer;
+    r : real;
+begin
+  x := 1;
+  r := 1.5 / (x - 1);
+  x := 2
+end.`, diagnostics.DivisionByZero, "6:12: Runtime error 200: division by zero"},
+		{`program RealDiv;
+var x : integer;
+begin
+  x := 1;
+  x := 7.5 div 2;
+  x := 2
+end.`, diagnostics.InvalidOperand, "5:12: operator div not applicable to REAL and INTEGER"},
+		{`program RealMod;
+var x : integer;
+    r : real;
+begin
+  x := 1;
+  r := 2;
+  x := 7 mod r;
+  x := 2
+end.`, diagnostics.InvalidOperand, "7:10: operator mod not applicable to INTEGER and REAL"},
+		{`program BoolMul;
+var x : integer;
+begin
+  x := 1;
+  x := true * 2;
+  x := 2
+end.`, diagnostics.InvalidOperand, "5:13: operator * not applicable to BOOLEAN and INTEGER"},
+		{`program IntCond;
+var x : integer;
+begin
+  x := 1;
+  if x then x := 3;
+  x := 2
+end.`, diagnostics.InvalidOperand, "5:6: condition must be BOOLEAN, got INTEGER"},
+		{`program WhileCond;
+var x : integer;
+begin
+  x := 1;
+  while 'a' do x := 3;
+  x := 2
+end.`, diagnostics.InvalidOperand, "5:9: condition must be BOOLEAN, got CHAR"},
+		{`program RepeatCond;
+var x : integer;
+begin
+  x := 1;
+  repeat until 1.5;
+  x := 2
+end.`, diagnostics.InvalidOperand, "5:16: condition must be BOOLEAN, got REAL"},
+		{`program BoolAnd;
+var x : integer;
+    b : boolean;
+begin
+  x := 1;
+  b := true and 1;
+  x := 2
+end.`, diagnostics.InvalidOperand, "6:13: operator and not applicable to BOOLEAN and INTEGER"},
+		{`program IntOr;
+var x : integer;
+    b : boolean;
+begin
+  x := 1;
+  b := 2 or 1.5;
+  x := 2
+end.`, diagnostics.InvalidOperand, "6:10: operator or not applicable to INTEGER and REAL"},
+		{`program ArgCount;
+var x : integer;
+procedure q(a, b : integer);
+begin
+  x := a + b
+end;
+begin
+  x := 1;
+  q(1);
+  x := 2
+end.`, diagnostics.InvalidArguments, "9:3: wrong number of arguments to q, got 1 and expected 2"},
+		{`program VarArg;
+var x : integer;
+procedure Inc(var n : integer);
+begin
+  n := n + 1
+end;
+begin
+  x := 1;
+  Inc(x + 1);
+  x := 2
+end.`, diagnostics.InvalidArguments, "9:3: argument for VAR parameter n of Inc must be a variable"},
+		{`program RealToInt;
+var x : integer;
+begin
+  x := 1;
+  x := 10 / 4;
+  x := 2
+end.`, diagnostics.InvalidValue, "5:8: cannot assign REAL to INTEGER variable x"},
+	}
+	for _, test := range tests {
+		inp := NewInterpreter(parser.NewParser(lexer.NewLexer(test.text)))
+		_, err := inp.Expr()
+		var runtimeErr *diagnostics.RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Fatalf("error is %+v; expected a runtime error, text is %s\n ", err, test.text)
+		}
+		if runtimeErr.Code != test.code || runtimeErr.Error() != test.want {
+			t.Errorf("error is %s %q; expected  %s %q\n ", runtimeErr.Code, runtimeErr.Error(), test.code, test.want)
+		}
+		// the program stops at the error, the statements after it do not run
+		records := inp.Stack.Records()
+		if x := records[len(records)-1].Members["X"]; x != Integer(1) {
+			t.Errorf("x is %+v; expected  %+v, text is %s\n ", x, Integer(1), test.text)
+		}
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`program Global;
+var x, y : integer;
+begin
+  x := y + 1
+end.`, "4:8: variable y is undefined"},
+		{`program Local;
+var x : integer;
+procedure P;
+var Count : integer;
+begin
+  Count := Count + 1
+end;
+begin
+  P
+end.`, "6:12: variable Count is undefined"},
+		{`program Loop;
+var i, x : integer;
+begin
+  for i := 1 to 3 do x := i;
+  x := i
+end.`, "5:8: variable i is undefined"},
+		{`program NoResult;
+var x : integer;
+function F(n : integer) : integer;
+begin
+  if n > 0 then F := n
+end;
+begin
+  x := F(1);
+  x := F(0)
+end.`, "9:8: result of function F is undefined"},
+	}
+	for _, test := range tests {
+		inp := NewInterpreter(parser.NewParser(lexer.NewLexer(test.text)))
+		inp.Strict = true
+		_, err := inp.Expr()
+		var runtimeErr *diagnostics.RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Fatalf("error is %+v; expected a runtime error, text is %s\n ", err, test.text)
+		}
+		if runtimeErr.Code != diagnostics.UndefinedVariable || runtimeErr.Error() != test.want {
+			t.Errorf("error is %s %q; expected  %s %q\n ", runtimeErr.Code, runtimeErr.Error(), diagnostics.UndefinedVariable, test.want)
+		}
+	}
+
+	text := `program Assigned;
+var x, y : integer;
+procedure Inc(var n : integer);
+begin
+  n := n + 1
+end;
+begin
+  y := 1;
+  Inc(y);
+  x := y * 2
+end.`
+	inp := NewInterpreter(parser.NewParser(lexer.NewLexer(text)))
+	inp.Strict = true
+	result, err := inp.Expr()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none, text is %s\n ", err, text)
+	}
+	if result["x"] != Integer(4) {
+		t.Errorf("x is %+v; expected  %+v, text is %s\n ", result["x"], Integer(4), text)
+	}
+
+	// locals are assigned in their own record, not in the program or a caller
+	text = `program Locals;
+var Count, r, x : integer;
+function f(n : integer) : integer;
+var t, count : integer;
+begin
+  t := n;
+  count := 100;
+  if n > 0 then r := f(n - 1);
+  f := t
+end;
+begin
+  Count := 1;
+  x := f(3)
+end.`
+	inp = NewInterpreter(parser.NewParser(lexer.NewLexer(text)))
+	inp.Strict = true
+	result, err = inp.Expr()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none, text is %s\n ", err, text)
+	}
+	expect := map[string]Value{"Count": Integer(1), "r": Integer(2), "x": Integer(3)}
+	if len(result) != len(expect) {
+		t.Errorf("globals are %+v; expected  %+v\n ", result, expect)
+	}
+	checkValues(t, text, result, expect)
+}
+
+func TestConstants(t *testing.T) {
+	var (
+		text = `program Consts;
+const Max = 10;
+      Rate : real = 2;
+      Greeting = 'hello' + ', ' + 'world';
+var total, kind : integer;
+    scaled : real;
+    text : string;
+function Sum(n : integer) : integer;
+const Step = 1;
+var i, acc : integer;
+begin
+  acc := 0;
+  for i := Step to n do acc := acc + i;
+  Sum := acc
+end;
+begin
+  total := Sum(Max);
+  scaled := Rate * Max;
+  text := Greeting;
+  case total of
+    0..Max: kind := 1;
+    Max + 1..Max * Max: kind := 2
+  else
+    kind := 3
+  end
+end.`
+		expect = map[string]Value{"total": Integer(55), "scaled": Real(20), "text": String("hello, world"), "kind": Integer(2)}
+	)
+	result := expectValues(t, text, expect)
+	// constants are no globals
+	if len(result) != len(expect) {
+		t.Errorf("result is %+v; expected  %+v\n ", result, expect)
+	}
+}
+
+func TestSubranges(t *testing.T) {
+	text := `program Ranges;
+type Index = 1..10;
+     Money = real;
+var i, total : Index;
+    price : Money;
+function Clip(n : integer) : Index;
+begin
+  if n > 10 then Clip := 10 else Clip := n
+end;
+begin
+  price := 3;
+  total := Clip(42);
+  for i := 1 to 3 do total := total - i;
+  i := total * 3
+end.`
+	expect := map[string]Value{"i": Integer(12), "total": Integer(4), "price": Real(3)}
+	expectValues(t, text, expect)
+
+	// with range checks on the last assignment is out of range
+	inp := NewInterpreter(parser.NewParser(lexer.NewLexer("{$R+}\n" + text)))
+	_, err := inp.Expr()
+	var runtimeErr *diagnostics.RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("error is %+v; expected a runtime error\n ", err)
+	}
+	want := "15:8: Runtime error 201: range check error, 12 is out of 1..10"
+	if runtimeErr.Code != diagnostics.RangeCheck || runtimeErr.Error() != want {
+		t.Errorf("error is %s %q; expected  %s %q\n ", runtimeErr.Code, runtimeErr.Error(), diagnostics.RangeCheck, want)
+	}
+}
+
+func TestEnumeratedTypes(t *testing.T) {
+	text := `program Enums;
+type Day = (Mon, Tue, Wed, Thu, Fri, Sat, Sun);
+const Last = High(Day);
+var d, first : Day;
+    n, days : integer;
+    later : boolean;
+procedure Next(var x : Day);
+begin
+  if x = High(x) then x := Low(Day) else x := Succ(x)
+end;
+function Weekend(x : Day) : boolean;
+type Part = Sat..Sun;
+begin
+  Weekend := (x >= Low(Part)) and (x <= High(Part))
+end;
+begin
+  d := Last;
+  Next(d);
+  first := d;
+  Next(d);
+  n := 0;
+  for d := Sun downto Mon do
+    if Weekend(d) then n := n + 1;
+  case Pred(Last) of
+    Mon..Fri: days := 0;
+    Sat: days := Ord(Sat);
+  end;
+  later := Wed > Tue;
+  d := Pred(first)
+end.`
+	inp := NewInterpreter(parser.NewParser(lexer.NewLexer(text)))
+	result, err := inp.Expr()
+	var runtimeErr *diagnostics.RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("error is %+v; expected a runtime error\n ", err)
+	}
+	want := "29:8: Runtime error 201: range check error, Pred(Mon) is out of the values of Day"
+	if runtimeErr.Code != diagnostics.RangeCheck || runtimeErr.Error() != want {
+		t.Errorf("error is %s %q; expected  %s %q\n ", runtimeErr.Code, runtimeErr.Error(), diagnostics.RangeCheck, want)
+	}
+
+	result = run(strings.Replace(text, "d := Pred(first)", "d := Succ(first)", 1))
+	expect := map[string]string{"first": "Mon", "d": "Tue", "n": "2", "days": "5", "later": "TRUE"}
+	for name, want := range expect {
+		if result[name] == nil || result[name].String() != want {
+			t.Errorf("%s is %+v; expected  %s, text is %s\n ", name, result[name], want, text)
+		}
+	}
+	if result["d"].Type() != "Day" {
+		t.Errorf("type of d is %s; expected Day\n ", result["d"].Type())
+	}
+}
+
+func TestBuiltinTypeBounds(t *testing.T) {
+	text := `program Bounds;
+var lo, hi : integer;
+    first, last : char;
+    no, yes : boolean;
+begin
+  lo := Low(Integer);
+  hi := High(Integer);
+  first := Low(Char);
+  last := High(Char);
+  no := Low(Boolean);
+  yes := High(Boolean)
+end.`
+	expectValues(t, text, map[string]Value{
+		"lo": Integer(math.MinInt64), "hi": Integer(math.MaxInt64),
+		"first": Char(0), "last": Char(255),
+		"no": Boolean(false), "yes": Boolean(true),
+	})
+}
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"fmt"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+)
+
+//typeInfo is a type declared in a TYPE section, it is a member of the record of the
+//routine declaring it. Base is the builtin type it stands for, a subrange has bounds
+//and enum is the enumerated type it stands for or nil
+type typeInfo struct {
+	base     token.Type
+	subrange bool
+	low      Value
+	high     Value
+	enum     *Enumeration
+}
+
+//visitTypeDecl declares a type of the running routine, the bounds of a subrange are
+//evaluated when its block starts and the values of an enumeration are constants of the routine
+func (inp *Interpreter) visitTypeDecl(t ast.TypeDecl) {
+	ar := inp.Stack.Peek()
+	info := inp.resolve(ar, t.Type)
+	switch {
+	case t.Values != nil:
+		enum := &Enumeration{Name: t.Node.Literal}
+		for i, value := range t.Values {
+			enum.Names = append(enum.Names, value.Literal)
+			canon := token.Canonical(value.Literal)
+			ar.Types[canon] = token.Type(enum.Name)
+			ar.Members[canon] = Enum{Ord: int64(i), Of: enum}
+		}
+		info = typeInfo{base: token.Type(enum.Name), enum: enum}
+	case t.Low != nil:
+		low := inp.visit(t.Low)
+		info = typeInfo{base: token.Type(low.Type()), subrange: true, low: low, high: inp.visit(t.High)}
+		if v, ok := low.(Enum); ok {
+			info.enum = v.Of
+		}
+	}
+	ar.Members[token.Canonical(t.Node.Literal)] = info
+}
+
+//resolve returns the type a type name stands for in the record from, a builtin type stands for itself
+func (inp *Interpreter) resolve(from *ActivationRecord, name token.Type) typeInfo {
+	canon := token.Canonical(string(name))
+	for ar := from; ar != nil; ar = ar.AccessLink {
+		if info, ok := ar.Members[canon].(typeInfo); ok {
+			return info
+		}
+	}
+	return typeInfo{base: name}
+}
+
+//declare sets the type of the member key of the record, the bounds of a subrange are kept
+//for the range checks and an enumerated type for the zero value
+func (ar *ActivationRecord) declare(key string, info typeInfo) {
+	ar.Types[key] = info.base
+	if info.subrange || info.enum != nil {
+		ar.declared[key] = info
+	}
+}
+
+//zero returns the value of the member key of the record before it is assigned
+func (ar *ActivationRecord) zero(key string) Value {
+	if info, ok := ar.declared[key]; ok && info.enum != nil {
+		return Enum{Of: info.enum}
+	}
+	return zeroValue(ar.Types[key])
+}
+
+//checkRange stops the program with runtime error 201 when the range checks are on and
+//the value stored in the member key of the record is out of its subrange, as Turbo Pascal does
+func (inp *Interpreter) checkRange(ar *ActivationRecord, key string, value Value, span ast.Span) {
+	info, ok := ar.declared[key]
+	if !inp.rangeChecks || !ok || !info.subrange {
+		return
+	}
+	if compare(value, info.low) >= 0 && compare(value, info.high) <= 0 {
+		return
+	}
+	msg := fmt.Sprintf("Runtime error 201: range check error, %s is out of %s..%s", value, info.low, info.high)
+	inp.fail(diagnostics.RangeCheck, span, msg)
+}
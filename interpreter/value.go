@@ -0,0 +1,189 @@
+package interpreter
+
+import (
+	"pascal_in_go/token"
+	"strconv"
+	"strings"
+)
+
+//Value is a runtime value, Type is the name of its Pascal type
+type Value interface {
+	Type() string
+	String() string
+}
+
+type Integer int64
+type Real float64
+type Boolean bool
+type Char byte
+type String string
+
+//Enumeration is an enumerated type, Names are the names of its values in order
+type Enumeration struct {
+	Name  string
+	Names []string
+}
+
+//Enum is a value of an enumerated type, Ord is its ordinal number
+type Enum struct {
+	Ord int64
+	Of  *Enumeration
+}
+
+func (v Integer) Type() string { return token.INTEGER }
+func (v Real) Type() string    { return token.REAL }
+func (v Boolean) Type() string { return token.BOOLEAN }
+func (v Char) Type() string    { return token.CHAR }
+func (v String) Type() string  { return token.STRING }
+func (v Enum) Type() string    { return v.Of.Name }
+
+func (v Integer) String() string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+//String of a real always shows it is not an integer, 2 is written 2.0
+func (v Real) String() string {
+	s := strconv.FormatFloat(float64(v), 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+func (v Boolean) String() string {
+	if v {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (v Char) String() string {
+	return string([]byte{byte(v)})
+}
+
+func (v String) String() string {
+	return string(v)
+}
+
+//String of a value of an enumerated type is its name
+func (v Enum) String() string {
+	return v.Of.Names[v.Ord]
+}
+
+//zeroValue returns the value of a variable of the type before it is assigned
+func zeroValue(typeName token.Type) Value {
+	switch typeName {
+	case token.BOOLEAN:
+		return Boolean(false)
+	case token.CHAR:
+		return Char(0)
+	case token.STRING:
+		return String("")
+	case token.REAL:
+		return Real(0)
+	}
+	return Integer(0)
+}
+
+//convert returns the value as stored in a variable of the type, an INTEGER
+//becomes a REAL and a CHAR a STRING, other values are left as they are
+func convert(value Value, typeName token.Type) Value {
+	switch v := value.(type) {
+	case Integer:
+		if typeName == token.REAL {
+			return Real(v)
+		}
+	case Char:
+		if typeName == token.STRING {
+			return String(v.String())
+		}
+	}
+	return value
+}
+
+//toReal returns a numeric value as a REAL
+func toReal(value Value) Real {
+	switch v := value.(type) {
+	case Integer:
+		return Real(v)
+	case Real:
+		return v
+	}
+	return 0
+}
+
+//ordinal returns the ordinal number of an integer, char, boolean or enumerated value
+func ordinal(value Value) int64 {
+	switch v := value.(type) {
+	case Integer:
+		return int64(v)
+	case Char:
+		return int64(v)
+	case Enum:
+		return v.Ord
+	case Boolean:
+		if v {
+			return 1
+		}
+	}
+	return 0
+}
+
+//fromOrdinal returns the value with the ordinal number, of the same type as like
+func fromOrdinal(ord int64, like Value) Value {
+	switch v := like.(type) {
+	case Char:
+		return Char(ord)
+	case Enum:
+		return Enum{Ord: ord, Of: v.Of}
+	case Boolean:
+		return Boolean(ord != 0)
+	}
+	return Integer(ord)
+}
+
+//isNumber reports whether the value is an INTEGER or a REAL
+func isNumber(value Value) bool {
+	switch value.(type) {
+	case Integer, Real:
+		return true
+	}
+	return false
+}
+
+//isText reports whether the value is a CHAR or a STRING
+func isText(value Value) bool {
+	switch value.(type) {
+	case Char, String:
+		return true
+	}
+	return false
+}
+
+//compare returns -1, 0 or 1 when left is less than, equal to or greater than right,
+//strings are compared by their characters and FALSE is less than TRUE
+func compare(left, right Value) int {
+	if isText(left) && isText(right) {
+		return strings.Compare(left.String(), right.String())
+	}
+	_, leftReal := left.(Real)
+	_, rightReal := right.(Real)
+	if leftReal || rightReal {
+		l, r := toReal(left), toReal(right)
+		if l < r {
+			return -1
+		}
+		if l > r {
+			return 1
+		}
+		return 0
+	}
+	l, r := ordinal(left), ordinal(right)
+	if l < r {
+		return -1
+	}
+	if l > r {
+		return 1
+	}
+	return 0
+}
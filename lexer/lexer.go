@@ -1,7 +1,11 @@
 package lexer
 
 import (
+	"fmt"
+	"pascal_in_go/diagnostics"
 	"pascal_in_go/token"
+	"strconv"
+	"strings"
 	"unicode"
 )
 
@@ -10,28 +14,77 @@ var ReservedKey = map[string]token.Token{
 	"BEGIN":     token.Token{Type: "BEGIN", Literal: "BEGIN"},
 	"END":       token.Token{Type: "END", Literal: "END"},
 	"VAR":       token.Token{Type: "VAR", Literal: "VAR"},
-	"DIV":       token.Token{Type: "DIV", Literal: "DIV"},
+	"DIV":       token.Token{Type: "INTEGER_DIV", Literal: "DIV"},
+	"MOD":       token.Token{Type: "MOD", Literal: "MOD"},
 	"INTEGER":   token.Token{Type: "INTEGER", Literal: "INTEGER"},
 	"REAL":      token.Token{Type: "REAL", Literal: "REAL"},
 	"PROGRAM":   token.Token{Type: "PROGRAM", Literal: "PROGRAM"},
 	"PROCEDURE": token.Token{Type: "PROCEDURE", Literal: "PROCEDURE"},
+	"STRING":    token.Token{Type: "STRING", Literal: "STRING"},
+	"CHAR":      token.Token{Type: "CHAR", Literal: "CHAR"},
+	"BOOLEAN":   token.Token{Type: "BOOLEAN", Literal: "BOOLEAN"},
+	"TRUE":      token.Token{Type: "TRUE", Literal: "TRUE"},
+	"FALSE":     token.Token{Type: "FALSE", Literal: "FALSE"},
+	"AND":       token.Token{Type: "AND", Literal: "AND"},
+	"OR":        token.Token{Type: "OR", Literal: "OR"},
+	"XOR":       token.Token{Type: "XOR", Literal: "XOR"},
+	"NOT":       token.Token{Type: "NOT", Literal: "NOT"},
+	"IF":        token.Token{Type: "IF", Literal: "IF"},
+	"THEN":      token.Token{Type: "THEN", Literal: "THEN"},
+	"ELSE":      token.Token{Type: "ELSE", Literal: "ELSE"},
+	"WHILE":     token.Token{Type: "WHILE", Literal: "WHILE"},
+	"DO":        token.Token{Type: "DO", Literal: "DO"},
+	"REPEAT":    token.Token{Type: "REPEAT", Literal: "REPEAT"},
+	"UNTIL":     token.Token{Type: "UNTIL", Literal: "UNTIL"},
+	"FOR":       token.Token{Type: "FOR", Literal: "FOR"},
+	"TO":        token.Token{Type: "TO", Literal: "TO"},
+	"DOWNTO":    token.Token{Type: "DOWNTO", Literal: "DOWNTO"},
+	"CASE":      token.Token{Type: "CASE", Literal: "CASE"},
+	"OF":        token.Token{Type: "OF", Literal: "OF"},
+	"OTHERWISE": token.Token{Type: "OTHERWISE", Literal: "OTHERWISE"},
+	"CONST":     token.Token{Type: "CONST", Literal: "CONST"},
+	"FUNCTION":  token.Token{Type: "FUNCTION", Literal: "FUNCTION"},
+	"TYPE":      token.Token{Type: "TYPE", Literal: "TYPE"},
 }
 
 type Lexer struct {
 	Text    string `json:"text"`
 	Pos     int    `json:"pos"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
 	CurChar byte   `json:"curChar"`
+	// KeepComments makes the lexer collect the skipped comments into Comments
+	KeepComments bool          `json:"keepComments"`
+	Comments     []token.Token `json:"comments"`
+	// Directives holds the compiler directives like {$mode delphi}, the literal is the text inside
+	Directives []token.Token `json:"directives"`
+	// ErrorList holds the errors found so far, each a *diagnostics.LexError
+	ErrorList []error `json:"errorList"`
 }
 
 func NewLexer(text string) Lexer {
-	return Lexer{Text: text, Pos: 0, CurChar: text[0]}
+	lexer := Lexer{Text: text, Pos: 0, Line: 1, Column: 1}
+	if len(text) > 0 {
+		lexer.CurChar = text[0]
+	}
+	return lexer
 }
 
+//NextToken returns the next token, with the position where it starts and ends
 func (lexer *Lexer) NextToken() token.Token {
+	lexer.skipTrivia()
+	start := lexer.position()
+	tok := lexer.scan()
+	tok.Pos = start
+	tok.End = lexer.position()
+	return tok
+}
+
+func (lexer *Lexer) scan() token.Token {
 	var tok token.Token
 	for lexer.CurChar != 0 {
-		if unicode.IsSpace(rune(lexer.CurChar)) {
-			lexer.skipWhiteSpace()
+		if unicode.IsSpace(rune(lexer.CurChar)) || lexer.isComment() {
+			lexer.skipTrivia()
 			continue
 		}
 
@@ -44,6 +97,10 @@ func (lexer *Lexer) NextToken() token.Token {
 			tok = lexer.number()
 			return tok
 		}
+		if lexer.CurChar == '\'' || lexer.CurChar == '#' {
+			tok = lexer.str()
+			return tok
+		}
 		if lexer.CurChar == '+' {
 			tok.Type = token.PLUS
 			tok.Literal = "+"
@@ -66,7 +123,7 @@ func (lexer *Lexer) NextToken() token.Token {
 		}
 
 		if lexer.CurChar == '/' {
-			tok.Type = token.DIV
+			tok.Type = token.REAL_DIV
 			tok.Literal = "/"
 			lexer.advance()
 			return tok
@@ -86,6 +143,14 @@ func (lexer *Lexer) NextToken() token.Token {
 			return tok
 		}
 
+		if lexer.CurChar == '.' && lexer.peek() == '.' {
+			tok.Type = token.RANGE
+			tok.Literal = ".."
+			lexer.advance()
+			lexer.advance()
+			return tok
+		}
+
 		if lexer.CurChar == '.' {
 			tok.Type = token.DOT
 			tok.Literal = "."
@@ -100,6 +165,41 @@ func (lexer *Lexer) NextToken() token.Token {
 			lexer.advance()
 			return tok
 		}
+		if lexer.CurChar == '=' {
+			lexer.advance()
+			tok.Type = token.EQ
+			tok.Literal = "="
+			return tok
+		}
+
+		if lexer.CurChar == '<' {
+			lexer.advance()
+			tok.Type = token.LT
+			tok.Literal = "<"
+			if lexer.CurChar == '>' {
+				lexer.advance()
+				tok.Type = token.NE
+				tok.Literal = "<>"
+			} else if lexer.CurChar == '=' {
+				lexer.advance()
+				tok.Type = token.LE
+				tok.Literal = "<="
+			}
+			return tok
+		}
+
+		if lexer.CurChar == '>' {
+			lexer.advance()
+			tok.Type = token.GT
+			tok.Literal = ">"
+			if lexer.CurChar == '=' {
+				lexer.advance()
+				tok.Type = token.GE
+				tok.Literal = ">="
+			}
+			return tok
+		}
+
 		if lexer.CurChar == ';' {
 			lexer.advance()
 			tok.Type = token.SEMI
@@ -121,14 +221,13 @@ func (lexer *Lexer) NextToken() token.Token {
 			return tok
 		}
 
-		if lexer.CurChar == 0 {
-			tok.Type = token.EOF
-			tok.Literal = ""
-			lexer.advance()
-			return tok
-		}
+		tok = newToken(token.ILLEGAL, lexer.CurChar)
+		start := lexer.position()
+		lexer.advance()
+		lexer.addError(diagnostics.IllegalCharacter, start, fmt.Sprintf("illegal character %q", tok.Literal[0]))
+		return tok
 	}
-	return newToken(token.ILLEGAL, lexer.CurChar)
+	return token.Token{Type: token.EOF, Literal: ""}
 }
 
 func (lexer *Lexer) isalpha() bool {
@@ -139,9 +238,12 @@ func (lexer *Lexer) isalpha() bool {
 	return false
 }
 
+//getIdentifier looks the reserved keys up case insensitively,
+//the literal keeps the original spelling
 func getIdentifier(val string) token.Token {
-	tok, ok := ReservedKey[val]
+	tok, ok := ReservedKey[token.Canonical(val)]
 	if ok {
+		tok.Literal = val
 		return tok
 	}
 	return token.Token{
@@ -150,7 +252,6 @@ func getIdentifier(val string) token.Token {
 	}
 }
 
-//
 func (lexer *Lexer) isnum() bool {
 	ch := lexer.CurChar
 	if ch >= '0' && ch <= '9' {
@@ -179,7 +280,8 @@ func (lexer *Lexer) number() token.Token {
 	tok.Type = token.INTEGER
 	tok.Literal = result
 
-	if lexer.CurChar == '.' {
+	// the dot of 1..9 is a range, not a decimal point
+	if lexer.CurChar == '.' && lexer.peek() != '.' {
 		result += "."
 		lexer.advance()
 		for lexer.CurChar != 0 && lexer.isnum() {
@@ -192,6 +294,74 @@ func (lexer *Lexer) number() token.Token {
 	return tok
 }
 
+//str reads a string literal, a sequence of quoted strings and #nn control
+//characters like 'It''s'#13#10, where a doubled quote stands for one quote.
+//The literal of the token holds the resulting characters
+func (lexer *Lexer) str() token.Token {
+	result := ""
+	for lexer.CurChar == '\'' || lexer.CurChar == '#' {
+		if lexer.CurChar == '#' {
+			start := lexer.position()
+			lexer.advance()
+			code, ok := lexer.charCode()
+			if !ok {
+				lexer.addError(diagnostics.InvalidCharCode, start, "invalid character code")
+				break
+			}
+			result += string([]byte{byte(code)})
+			continue
+		}
+
+		start := lexer.position()
+		lexer.advance()
+		for {
+			if lexer.CurChar == 0 || lexer.CurChar == '\n' {
+				lexer.addError(diagnostics.UnterminatedString, start, "unterminated string")
+				return token.Token{Type: token.STRING_CONST, Literal: result}
+			}
+			if lexer.CurChar == '\'' {
+				lexer.advance()
+				if lexer.CurChar != '\'' {
+					break
+				}
+			}
+			result += string(lexer.CurChar)
+			lexer.advance()
+		}
+	}
+
+	if len(result) == 1 {
+		return token.Token{Type: token.CHAR_CONST, Literal: result}
+	}
+	return token.Token{Type: token.STRING_CONST, Literal: result}
+}
+
+//charCode reads the code after #, a decimal number or a hexadecimal one prefixed with $
+func (lexer *Lexer) charCode() (int, bool) {
+	base := 10
+	if lexer.CurChar == '$' {
+		base = 16
+		lexer.advance()
+	}
+	digits := ""
+	for lexer.CurChar != 0 && isDigit(lexer.CurChar, base) {
+		digits += string(lexer.CurChar)
+		lexer.advance()
+	}
+	code, err := strconv.ParseInt(digits, base, 64)
+	if err != nil || code > 255 {
+		return 0, false
+	}
+	return int(code), true
+}
+
+func isDigit(ch byte, base int) bool {
+	if ch >= '0' && ch <= '9' {
+		return true
+	}
+	return base == 16 && ((ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F'))
+}
+
 func (lexer *Lexer) letter() string {
 	result := ""
 	result += string(lexer.CurChar)
@@ -205,13 +375,89 @@ func (lexer *Lexer) letter() string {
 	return result
 }
 
+//skipTrivia skips the white space and comments before the next token
+func (lexer *Lexer) skipTrivia() {
+	for {
+		if unicode.IsSpace(rune(lexer.CurChar)) {
+			lexer.skipWhiteSpace()
+			continue
+		}
+		if lexer.isComment() {
+			lexer.comment()
+			continue
+		}
+		return
+	}
+}
+
+//isComment reports whether a comment starts at the current char: { }, (* *) or //
+func (lexer *Lexer) isComment() bool {
+	ch := lexer.CurChar
+	return ch == '{' || (ch == '(' && lexer.peek() == '*') || (ch == '/' && lexer.peek() == '/')
+}
+
+//comment consumes a comment, an unterminated comment runs until the end of text
+func (lexer *Lexer) comment() {
+	start := lexer.position()
+	if lexer.CurChar == '/' {
+		for lexer.CurChar != 0 && lexer.CurChar != '\n' {
+			lexer.advance()
+		}
+	} else {
+		closing := "}"
+		if lexer.CurChar == '(' {
+			closing = "*)"
+			lexer.advance()
+		}
+		lexer.advance()
+		for lexer.CurChar != 0 && !strings.HasPrefix(lexer.Text[lexer.Pos:], closing) {
+			lexer.advance()
+		}
+		if lexer.CurChar == 0 {
+			lexer.addError(diagnostics.UnterminatedComment, start, "unterminated comment")
+		}
+		for i := 0; i < len(closing) && lexer.CurChar != 0; i++ {
+			lexer.advance()
+		}
+	}
+
+	literal := lexer.Text[start.Offset:lexer.Pos]
+	if strings.HasPrefix(literal, "{$") || strings.HasPrefix(literal, "(*$") {
+		text := strings.TrimSuffix(strings.TrimSuffix(literal, "}"), "*)")
+		text = strings.TrimSpace(strings.TrimLeft(text, "{(*$"))
+		tok := token.Token{Type: token.DIRECTIVE, Literal: text, Pos: start, End: lexer.position()}
+		lexer.Directives = append(lexer.Directives, tok)
+	}
+	if lexer.KeepComments {
+		tok := token.Token{Type: token.COMMENT, Literal: literal, Pos: start, End: lexer.position()}
+		lexer.Comments = append(lexer.Comments, tok)
+	}
+}
+
+//addError records a LexError spanning from start to the current position
+func (lexer *Lexer) addError(code diagnostics.Code, start token.Position, msg string) {
+	span := token.Span{Start: start, End: lexer.position()}
+	lexer.ErrorList = append(lexer.ErrorList, diagnostics.NewLexError(code, span, msg))
+}
+
 func (lexer *Lexer) skipWhiteSpace() {
-	for lexer.CurChar == ' ' || lexer.CurChar == '\t' || lexer.CurChar == '\r' || lexer.CurChar == '\n' {
+	for lexer.CurChar != 0 && unicode.IsSpace(rune(lexer.CurChar)) {
 		lexer.advance()
 	}
 }
 
+//position returns the position of the current char
+func (lexer *Lexer) position() token.Position {
+	return token.Position{Offset: lexer.Pos, Line: lexer.Line, Column: lexer.Column}
+}
+
 func (lexer *Lexer) advance() {
+	if lexer.CurChar == '\n' {
+		lexer.Line++
+		lexer.Column = 1
+	} else {
+		lexer.Column++
+	}
 	lexer.Pos++
 	if lexer.Pos > len(lexer.Text)-1 {
 		lexer.CurChar = 0
@@ -224,7 +470,7 @@ func (lexer *Lexer) advance() {
 func (lexer *Lexer) peek() byte {
 	pos := lexer.Pos + 1
 	var curChar byte = 0
-	if pos < len(lexer.Text)-1 {
+	if pos < len(lexer.Text) {
 		curChar = lexer.Text[pos]
 	}
 
@@ -1,6 +1,9 @@
 package lexer
 
-import "testing"
+import (
+	"pascal_in_go/token"
+	"testing"
+)
 
 func TestIsalpha(t *testing.T) {
 	var (
@@ -25,3 +28,224 @@ func TestIsnum(t *testing.T) {
 		t.Errorf("flag is %+v; expected  %+v, text is %s\n ", flag, expect, text)
 	}
 }
+
+func TestTokenPosition(t *testing.T) {
+	var (
+		text   = "BEGIN\n  a := 10\nEND"
+		expect = []token.Token{
+			{Type: token.BEGIN, Literal: "BEGIN", Pos: token.Position{Offset: 0, Line: 1, Column: 1}, End: token.Position{Offset: 5, Line: 1, Column: 6}},
+			{Type: token.ID, Literal: "a", Pos: token.Position{Offset: 8, Line: 2, Column: 3}, End: token.Position{Offset: 9, Line: 2, Column: 4}},
+			{Type: token.ASSIGN, Literal: ":=", Pos: token.Position{Offset: 10, Line: 2, Column: 5}, End: token.Position{Offset: 12, Line: 2, Column: 7}},
+			{Type: token.INTEGER, Literal: "10", Pos: token.Position{Offset: 13, Line: 2, Column: 8}, End: token.Position{Offset: 15, Line: 2, Column: 10}},
+			{Type: token.END, Literal: "END", Pos: token.Position{Offset: 16, Line: 3, Column: 1}, End: token.Position{Offset: 19, Line: 3, Column: 4}},
+		}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+}
+
+func TestSkipComments(t *testing.T) {
+	var (
+		text   = "{ brace } a (* paren\n *) := // line\n 1"
+		expect = []token.Type{token.ID, token.ASSIGN, token.INTEGER, token.EOF}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+	if len(lexer.ErrorList) != 0 {
+		t.Errorf("errors are %+v; expected none, text is %s\n ", lexer.ErrorList, text)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	var (
+		text   = "a (* never closed"
+		expect = []token.Type{token.ID, token.EOF}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+	if len(lexer.ErrorList) != 1 {
+		t.Errorf("errors are %+v; expected one, text is %s\n ", lexer.ErrorList, text)
+	}
+}
+
+func TestKeepComments(t *testing.T) {
+	var (
+		text   = "{ one } a // two\n"
+		expect = []string{"{ one }", "// two"}
+	)
+	lexer := NewLexer(text)
+	lexer.KeepComments = true
+	for lexer.NextToken().Type != token.EOF {
+	}
+	if len(lexer.Comments) != len(expect) {
+		t.Fatalf("comments are %+v; expected  %+v, text is %s\n ", lexer.Comments, expect, text)
+	}
+	for i, want := range expect {
+		if lexer.Comments[i].Type != token.COMMENT || lexer.Comments[i].Literal != want {
+			t.Errorf("comment is %+v; expected  %+v, text is %s\n ", lexer.Comments[i], want, text)
+		}
+	}
+}
+
+func TestIllegalCharacter(t *testing.T) {
+	var (
+		text   = "a ? b"
+		expect = []token.Type{token.ID, token.ILLEGAL, token.ID, token.EOF}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+	if len(lexer.ErrorList) != 1 {
+		t.Errorf("errors are %+v; expected one, text is %s\n ", lexer.ErrorList, text)
+	}
+}
+
+func TestCaseInsensitiveKeywords(t *testing.T) {
+	var (
+		text   = "begin Begin BEGIN Number"
+		expect = []token.Token{
+			{Type: token.BEGIN, Literal: "begin"},
+			{Type: token.BEGIN, Literal: "Begin"},
+			{Type: token.BEGIN, Literal: "BEGIN"},
+			{Type: token.ID, Literal: "Number"},
+		}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	var (
+		text   = "'hello' 'It''s' #13#10 'a' #$41 'x'#9'y' ''"
+		expect = []token.Token{
+			{Type: token.STRING_CONST, Literal: "hello"},
+			{Type: token.STRING_CONST, Literal: "It's"},
+			{Type: token.STRING_CONST, Literal: "\r\n"},
+			{Type: token.CHAR_CONST, Literal: "a"},
+			{Type: token.CHAR_CONST, Literal: "A"},
+			{Type: token.STRING_CONST, Literal: "x\ty"},
+			{Type: token.STRING_CONST, Literal: ""},
+			{Type: token.EOF, Literal: ""},
+		}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+	if len(lexer.ErrorList) != 0 {
+		t.Errorf("errors are %+v; expected none, text is %s\n ", lexer.ErrorList, text)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	var (
+		text   = "'abc\n;"
+		expect = []token.Type{token.STRING_CONST, token.SEMI, token.EOF}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+	if len(lexer.ErrorList) != 1 {
+		t.Errorf("errors are %+v; expected one, text is %s\n ", lexer.ErrorList, text)
+	}
+}
+
+func TestRelationalOperators(t *testing.T) {
+	var (
+		text   = "= <> < <= > >= and Or NOT xor"
+		expect = []token.Type{token.EQ, token.NE, token.LT, token.LE, token.GT, token.GE,
+			token.AND, token.OR, token.NOT, token.XOR, token.EOF}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	var (
+		text   = "1..9 1.5 'a'..'z'"
+		expect = []token.Token{
+			{Type: token.INTEGER, Literal: "1"},
+			{Type: token.RANGE, Literal: ".."},
+			{Type: token.INTEGER, Literal: "9"},
+			{Type: token.REAL, Literal: "1.5"},
+			{Type: token.CHAR_CONST, Literal: "a"},
+			{Type: token.RANGE, Literal: ".."},
+			{Type: token.CHAR_CONST, Literal: "z"},
+		}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	text := "{$mode delphi} (*$H+*) { plain } x"
+	lexer := NewLexer(text)
+	tok := lexer.NextToken()
+	if tok.Type != token.ID || tok.Literal != "x" {
+		t.Errorf("token is %+v; expected ID x, text is %s\n ", tok, text)
+	}
+	expect := []string{"mode delphi", "H+"}
+	if len(lexer.Directives) != len(expect) {
+		t.Fatalf("directives are %+v; expected  %+v\n ", lexer.Directives, expect)
+	}
+	for i, want := range expect {
+		if lexer.Directives[i].Literal != want {
+			t.Errorf("directive is %q; expected  %q\n ", lexer.Directives[i].Literal, want)
+		}
+	}
+}
+
+func TestDivisionOperators(t *testing.T) {
+	var (
+		text   = "a / b div c Mod d"
+		expect = []token.Type{token.ID, token.REAL_DIV, token.ID, token.INTEGER_DIV, token.ID, token.MOD, token.ID}
+	)
+	lexer := NewLexer(text)
+	for _, want := range expect {
+		tok := lexer.NextToken()
+		if tok.Type != want {
+			t.Errorf("token is %+v; expected  %+v, text is %s\n ", tok, want, text)
+		}
+	}
+}
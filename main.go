@@ -1,17 +1,23 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"pascal_in_go/interpreter"
 	"pascal_in_go/lexer"
 	"pascal_in_go/parser"
+	"pascal_in_go/token"
+	"sort"
+	"strings"
 )
 
 func main() {
+	strict := flag.Bool("strict", false, "make reading a variable before it is assigned a runtime error")
+	flag.Parse()
 
-	filename := os.Args[1]
+	filename := flag.Arg(0)
 	stream, err := ioutil.ReadFile(filename)
 	if err != nil {
 		panic(err)
@@ -23,7 +29,26 @@ func main() {
 	lexer := lexer.NewLexer(text)
 	parser := parser.NewParser(lexer)
 	inp := interpreter.NewInterpreter(parser)
-	result := inp.Expr()
-	fmt.Printf("global variables: %+v\n", result)
+	inp.Strict = *strict
+	result, err := inp.Expr()
+	if err != nil {
+		fmt.Println("error: ", err)
+		os.Exit(1)
+	}
+	names := make([]string, 0, len(result))
+	for name := range result {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println("global variables: ")
+	for _, name := range names {
+		value := result[name]
+		text := value.String()
+		// chars and strings are written as pascal literals
+		if value.Type() == token.CHAR || value.Type() == token.STRING {
+			text = "'" + strings.ReplaceAll(text, "'", "''") + "'"
+		}
+		fmt.Printf("%s : %s = %s\n", name, inp.TypeMap[name], text)
+	}
 
 }
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+	"sort"
+	"strings"
+)
+
+//ErrorList is the error of a program with lexical or syntax errors, in the order
+//of their positions, errors.As finds the first one
+type ErrorList []error
+
+func (list ErrorList) Error() string {
+	msgs := make([]string, 0, len(list))
+	for _, err := range list {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+//Errors returns the errors of the lexer and of the parser in the order of their positions
+func (parser *Parser) Errors() ErrorList {
+	list := make(ErrorList, 0, len(parser.Lexer.ErrorList)+len(parser.ErrorList))
+	list = append(list, parser.Lexer.ErrorList...)
+	list = append(list, parser.ErrorList...)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].(diagnostics.Diagnostic).GetSpan().Start.Offset < list[j].(diagnostics.Diagnostic).GetSpan().Start.Offset
+	})
+	return list
+}
+
+func (list ErrorList) Unwrap() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list[0]
+}
+
+//syncTokens holds the tokens parsing goes on from after a syntax error
+var syncTokens = []token.Type{token.SEMI, token.END, token.BEGIN, token.VAR, token.CONST, token.TYPE, token.PROCEDURE, token.FUNCTION, token.EOF}
+
+//fail stops parsing at the current token with a *diagnostics.SyntaxError,
+//the nearest guard records it
+func (parser *Parser) fail(expected token.Type) {
+	panic(diagnostics.NewSyntaxError(expected, parser.CurToken))
+}
+
+//error records a syntax error at the current token and parsing goes on,
+//a second error at the same position is dropped
+func (parser *Parser) error(expected token.Type) {
+	parser.addError(diagnostics.NewSyntaxError(expected, parser.CurToken))
+}
+
+func (parser *Parser) addError(err *diagnostics.SyntaxError) {
+	if n := len(parser.ErrorList); n > 0 && parser.ErrorList[n-1].(*diagnostics.SyntaxError).Span.Start == err.Span.Start {
+		return
+	}
+	parser.ErrorList = append(parser.ErrorList, err)
+}
+
+//expect eats a token of the type, or records a syntax error without eating anything
+func (parser *Parser) expect(tokenType token.Type) {
+	if parser.CurToken.Type != tokenType {
+		parser.error(tokenType)
+		return
+	}
+	parser.eat(tokenType)
+}
+
+//guard runs parse and reports whether it succeeded, the error of a fail in it is recorded
+func (parser *Parser) guard(parse func()) (ok bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		syntaxErr, isSyntax := r.(*diagnostics.SyntaxError)
+		if !isSyntax {
+			panic(r)
+		}
+		parser.addError(syntaxErr)
+		ok = false
+	}()
+	parse()
+	return true
+}
+
+//synchronize skips the tokens up to one parsing can go on from, see syncTokens
+func (parser *Parser) synchronize() {
+	for !isInSlice(parser.CurToken.Type, syncTokens) {
+		parser.PrevEnd = parser.CurToken.End
+		parser.CurToken = nextToken(&parser.Lexer)
+	}
+}
@@ -1,10 +1,10 @@
 package parser
 
 import (
-	"log"
 	"pascal_in_go/ast"
 	"pascal_in_go/lexer"
 	"pascal_in_go/token"
+	"strings"
 )
 
 /* context free grammar
@@ -12,123 +12,423 @@ program : PROGRAM Variable SEMI block DOT
 
 block : declarations compound_statement
 
-declarations :  VAR(variable_declaration SEMI)+  | empty
+declarations :  (CONST (constant_declaration SEMI)+ | TYPE (type_declaration SEMI)+
+		| VAR (variable_declaration SEMI)+ | procedure_declaration | function_declaration)*
+
+constant_declaration : ID (COLON type_spec)? EQ expr
+
+type_declaration : ID EQ (type_spec | subrange | enumeration)
+
+subrange : expr RANGE expr
+
+enumeration : LPAREN ID (COMMA ID)* RPAREN
+
+procedure_declaration : PROCEDURE ID (LPAREN formal_parameter_list RPAREN)? SEMI block SEMI
+
+function_declaration : FUNCTION ID (LPAREN formal_parameter_list RPAREN)? COLON type_spec SEMI block SEMI
+
+formal_parameter_list : formal_parameters (SEMI formal_parameters)*
+
+formal_parameters : (VAR | CONST)? ID (COMMA ID)* COLON type_spec
 
 variable_declaration : ID(COMMA ID)* COLON type_spec
 
-type_spec : INTEGER | REAL
+type_spec : INTEGER | REAL | CHAR | STRING | BOOLEAN | ID
 
 compound_statement :  BEGIN   statement_list  END
 
 statement_list : statement | statement SEMI  statement_list
 
-statement :  compound_statement | assignment  | empty
+statement :  compound_statement | if_statement | while_statement | repeat_statement | for_statement
+		| case_statement | assignment  | proccall_statement | empty
+
+proccall_statement : ID (LPAREN (expr (COMMA expr)*)? RPAREN)?
+
+funccall_expression : ID LPAREN (expr (COMMA expr)*)? RPAREN
+
+if_statement : IF expr THEN statement (ELSE statement)?
+
+while_statement : WHILE expr DO statement
+
+repeat_statement : REPEAT statement_list UNTIL expr
+
+for_statement : FOR variable ASSIGN expr (TO | DOWNTO) expr DO statement
+
+case_statement : CASE expr OF case_element (SEMI case_element)* SEMI? ((ELSE | OTHERWISE) statement_list)? END
+
+case_element : case_label (COMMA case_label)* COLON statement
+
+case_label : expr (RANGE expr)?
 
 assignment :  variable  ASSIGN expr
 
-expr : term ((PLUS |  MINUS) term )*
+expr : simple_expression ((EQ | NE | LT | LE | GT | GE) simple_expression)?
+
+simple_expression : term ((PLUS | MINUS | OR | XOR) term )*
 
-term : factor ((MUL | INTEGER_DIV | FLOAT_DIV) factor )*
+term : factor ((MUL | INTEGER_DIV | REAL_DIV | MOD | AND) factor )*
 
 factor :  PLUS factor
 		| MINUS factor
+		| NOT factor
+		| TRUE
+		| FALSE
 		| REAL_CONST
 		| INTEGER_CONST
+		| STRING_CONST
+		| CHAR_CONST
 		| Lparenthesized expr Rparenthesized
+		| funccall_expression
 		| variable
 
 variable :  ID
 */
 
-//INF represents the infinity
-const INF = 0x3fffffff
-
 //Parser struct
 type Parser struct {
 	Lexer    lexer.Lexer `json:"lexer"`
 	CurToken token.Token `json:"curToken"`
+	// PrevEnd is the end position of the last eaten token
+	PrevEnd token.Position `json:"prevEnd"`
+	// ErrorList holds the syntax errors found so far, each a *diagnostics.SyntaxError
+	ErrorList []error `json:"errorList"`
 }
 
 // NewParser  init the parser
 func NewParser(lexer lexer.Lexer) *Parser {
-	return &Parser{Lexer: lexer, CurToken: lexer.NextToken()}
+	parser := &Parser{Lexer: lexer}
+	parser.CurToken = nextToken(&parser.Lexer)
+	return parser
 }
 
-func (parser *Parser) Program() ast.Expr {
+//nextToken returns the next token of the lexer, an illegal character is skipped as the
+//lexer reports it already
+func nextToken(lexer *lexer.Lexer) token.Token {
+	tok := lexer.NextToken()
+	for tok.Type == token.ILLEGAL {
+		tok = lexer.NextToken()
+	}
+	return tok
+}
+
+//Program parses a whole program, it goes on after a syntax error and returns the tree
+//parsed with the lexical and syntax errors in an ErrorList
+func (parser *Parser) Program() (ast.Expr, error) {
 	/*
 		program : PROGRAM Variable SEMI block DOT
 	*/
-	parser.eat(token.PROGRAM)
-	varNode := parser.variable()
-	name := varNode.ToStr()
-	parser.eat(token.SEMI)
-	block := parser.block()
-	parser.eat(token.DOT)
-	return ast.Program{Block: block, Name: name}
+	start := parser.CurToken.Pos
+	name := ""
+	header := parser.guard(func() {
+		parser.eat(token.PROGRAM)
+		name = parser.variable().ToStr()
+		parser.eat(token.SEMI)
+	})
+	if !header {
+		parser.synchronize()
+		if parser.CurToken.Type == token.SEMI {
+			parser.eat(token.SEMI)
+		}
+	}
+	var block ast.Block
+	parser.guard(func() {
+		block = parser.block()
+		parser.expect(token.DOT)
+	})
+	tree := ast.Program{Span: parser.span(start), Block: block, Name: name, Mode: parser.mode(), RangeChecks: parser.rangeChecks()}
+	if list := parser.Errors(); len(list) > 0 {
+		return tree, list
+	}
+	return tree, nil
 }
 
+//mode returns the compiler mode set by a {$mode name} directive, upper cased
+func (parser *Parser) mode() string {
+	mode := ""
+	for _, directive := range parser.Lexer.Directives {
+		fields := strings.Fields(directive.Literal)
+		if len(fields) == 2 && token.Canonical(fields[0]) == "MODE" {
+			mode = token.Canonical(fields[1])
+		}
+	}
+	return mode
+}
+
+//rangeChecks reports whether a {$R+} or {$RANGECHECKS ON} directive turns the runtime
+//range checks on, the last of them wins
+func (parser *Parser) rangeChecks() bool {
+	on := false
+	for _, directive := range parser.Lexer.Directives {
+		switch token.Canonical(strings.Join(strings.Fields(directive.Literal), " ")) {
+		case "R+", "RANGECHECKS ON":
+			on = true
+		case "R-", "RANGECHECKS OFF":
+			on = false
+		}
+	}
+	return on
+}
+
+//block parses the declarations and the compound statement, after a syntax error at its
+//BEGIN the statements up to the END closing it are skipped and the declarations are kept
 func (parser *Parser) block() ast.Block {
 	//block : declarations compound_statement
+	start := parser.CurToken.Pos
 	declarations := parser.declarations()
-	compound := parser.comStatement()
-	return ast.Block{Decl: declarations, Compound: compound}
+	var compound ast.Compound
+	if !parser.guard(func() { compound = parser.comStatement() }) {
+		parser.synchronize()
+		for parser.CurToken.Type == token.SEMI {
+			parser.eat(token.SEMI)
+			parser.synchronize()
+		}
+		if parser.CurToken.Type == token.END {
+			parser.eat(token.END)
+		}
+	}
+	return ast.Block{Span: parser.span(start), Decl: declarations, Compound: compound}
+}
+
+// span returns the span from start to the end of the last eaten token
+func (parser *Parser) span(start token.Position) ast.Span {
+	return ast.Span{Start: start, End: parser.PrevEnd}
 }
 
 func (parser *Parser) declarations() ast.Decl {
 	/*
-		declarations : VAR (variable_declaration SEMI)+
-						| (PROCEDURE ID SEMI block SEMI)*
-		               | empty
+		declarations : (CONST (constant_declaration SEMI)+
+						| TYPE (type_declaration SEMI)+
+						| VAR (variable_declaration SEMI)+
+						| procedure_declaration
+						| function_declaration)*
 	*/
-	decls := ast.Decl{}
-	vardecls := make([]ast.VarDecl, 0)
-	if parser.CurToken.Type == token.VAR {
-		parser.eat(token.VAR)
-		for parser.CurToken.Type == token.ID {
-			varDecls := parser.varDecl()
-			vardecls = append(vardecls, varDecls...)
+	start := parser.CurToken.Pos
+	decls := ast.Decl{
+		ConstDeclList: make([]ast.ConstDecl, 0),
+		TypeDeclList:  make([]ast.TypeDecl, 0),
+		VarDeclList:   make([]ast.VarDecl, 0),
+		ProceDeclList: make([]ast.Procedure, 0),
+		FuncDeclList:  make([]ast.Function, 0),
+	}
+	for {
+		switch parser.CurToken.Type {
+		case token.CONST:
+			parser.eat(token.CONST)
+			parser.section(func() {
+				decls.ConstDeclList = append(decls.ConstDeclList, parser.constDecl())
+			})
+		case token.TYPE:
+			parser.eat(token.TYPE)
+			parser.section(func() {
+				decls.TypeDeclList = append(decls.TypeDeclList, parser.typeDecl())
+			})
+		case token.VAR:
+			parser.eat(token.VAR)
+			parser.section(func() {
+				decls.VarDeclList = append(decls.VarDeclList, parser.varDecl()...)
+			})
+		case token.PROCEDURE:
+			decls.ProceDeclList = append(decls.ProceDeclList, parser.procedureDecl())
+		case token.FUNCTION:
+			decls.FuncDeclList = append(decls.FuncDeclList, parser.functionDecl())
+		default:
+			decls.Span = parser.span(start)
+			// an empty section ends where it starts
+			if parser.CurToken.Pos == start {
+				decls.Span.End = start
+			}
+			return decls
+		}
+	}
+}
+
+//section parses the declarations of a CONST, TYPE or VAR section, each followed by SEMI,
+//after a syntax error in one the tokens up to its end are skipped
+func (parser *Parser) section(parse func()) {
+	for parser.CurToken.Type == token.ID {
+		ok := parser.guard(func() {
+			parse()
 			parser.eat(token.SEMI)
+		})
+		if !ok {
+			parser.synchronize()
+			if parser.CurToken.Type == token.SEMI {
+				parser.eat(token.SEMI)
+			}
 		}
 	}
-	decls.VarDeclList = vardecls
+}
+
+func (parser *Parser) procedureDecl() ast.Procedure {
+	/*
+		procedure_declaration : PROCEDURE ID (LPAREN formal_parameter_list RPAREN)? SEMI block SEMI
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.PROCEDURE)
+	procedure := ast.Procedure{Name: parser.CurToken.Literal, Params: make([]ast.Param, 0)}
+	parser.header(func() {
+		parser.eat(token.ID)
+		procedure.Params = parser.formalParameters()
+		parser.eat(token.SEMI)
+	})
+	procedure.Block = parser.block()
+	parser.expect(token.SEMI)
+	procedure.Span = parser.span(start)
+	return procedure
+}
 
-	procedureList := make([]ast.Procedure, 0)
-	for parser.CurToken.Type == token.PROCEDURE {
-		parser.eat(token.PROCEDURE)
-		procedName := parser.CurToken.Literal
+func (parser *Parser) functionDecl() ast.Function {
+	/*
+		function_declaration : FUNCTION ID (LPAREN formal_parameter_list RPAREN)? COLON type_spec SEMI block SEMI
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.FUNCTION)
+	function := ast.Function{Name: parser.CurToken.Literal, Params: make([]ast.Param, 0)}
+	parser.header(func() {
 		parser.eat(token.ID)
+		function.Params = parser.formalParameters()
+		parser.eat(token.COLON)
+		function.ReturnType = parser.typeSpec()
 		parser.eat(token.SEMI)
-		block := parser.block()
-		procedure := ast.Procedure{Name: procedName, Block: block}
-		procedureList = append(procedureList, procedure)
+	})
+	function.Block = parser.block()
+	parser.expect(token.SEMI)
+	function.Span = parser.span(start)
+	return function
+}
+
+//header parses the heading of a procedure or function, after a syntax error in it
+//the tokens up to the block are skipped
+func (parser *Parser) header(parse func()) {
+	if parser.guard(parse) {
+		return
+	}
+	parser.synchronize()
+	if parser.CurToken.Type == token.SEMI {
 		parser.eat(token.SEMI)
+	}
+}
 
+//formalParameters parses the optional parenthesized formal parameter list
+func (parser *Parser) formalParameters() []ast.Param {
+	params := make([]ast.Param, 0)
+	if parser.CurToken.Type == token.LPAREN {
+		parser.eat(token.LPAREN)
+		params = parser.formalParameterList()
+		parser.eat(token.RPAREN)
 	}
-	decls.ProceDeclList = procedureList
-	return decls
+	return params
+}
+
+func (parser *Parser) formalParameterList() []ast.Param {
+	/*
+		formal_parameter_list : formal_parameters (SEMI formal_parameters)*
+
+		formal_parameters : (VAR | CONST)? ID (COMMA ID)* COLON type_spec
+	*/
+	params := make([]ast.Param, 0)
+	for {
+		start := parser.CurToken.Pos
+		var mode token.Type
+		if parser.CurToken.Type == token.VAR || parser.CurToken.Type == token.CONST {
+			mode = parser.CurToken.Type
+			parser.eat(mode)
+		}
+		varNodes := []ast.VarNode{parser.variable().(ast.VarNode)}
+		for parser.CurToken.Type == token.COMMA {
+			parser.eat(token.COMMA)
+			varNodes = append(varNodes, parser.variable().(ast.VarNode))
+		}
+		parser.eat(token.COLON)
+		typeSpec := parser.typeSpec()
+		for _, elem := range varNodes {
+			params = append(params, ast.Param{Span: parser.span(start), Node: elem, Type: typeSpec, Mode: mode})
+		}
+
+		if parser.CurToken.Type != token.SEMI {
+			return params
+		}
+		parser.eat(token.SEMI)
+	}
+}
+
+func (parser *Parser) constDecl() ast.ConstDecl {
+	/*
+		constant_declaration : ID (COLON type_spec)? EQ expr
+	*/
+	start := parser.CurToken.Pos
+	decl := ast.ConstDecl{Node: parser.variable().(ast.VarNode)}
+	if parser.CurToken.Type == token.COLON {
+		parser.eat(token.COLON)
+		decl.Type = parser.typeSpec()
+	}
+	parser.eat(token.EQ)
+	decl.Value = parser.expr()
+	decl.Span = parser.span(start)
+	return decl
+}
+
+func (parser *Parser) typeDecl() ast.TypeDecl {
+	/*
+		type_declaration : ID EQ (type_spec | subrange | enumeration)
+
+		subrange : expr RANGE expr
+
+		enumeration : LPAREN ID (COMMA ID)* RPAREN
+	*/
+	start := parser.CurToken.Pos
+	decl := ast.TypeDecl{Node: parser.variable().(ast.VarNode)}
+	parser.eat(token.EQ)
+	if parser.isBuiltinType() {
+		decl.Type = parser.typeSpec()
+		decl.Span = parser.span(start)
+		return decl
+	}
+	// a parenthesis starts an enumeration, not the lower bound of a subrange
+	if parser.CurToken.Type == token.LPAREN {
+		parser.eat(token.LPAREN)
+		decl.Values = []ast.VarNode{parser.variable().(ast.VarNode)}
+		for parser.CurToken.Type == token.COMMA {
+			parser.eat(token.COMMA)
+			decl.Values = append(decl.Values, parser.variable().(ast.VarNode))
+		}
+		parser.eat(token.RPAREN)
+		decl.Span = parser.span(start)
+		return decl
+	}
+	// a type name and the lower bound of a subrange may both start with an ID
+	low := parser.expr()
+	if parser.CurToken.Type == token.RANGE {
+		parser.eat(token.RANGE)
+		decl.Low = low
+		decl.High = parser.expr()
+	} else if name, ok := low.(ast.VarNode); ok {
+		decl.Type = token.Type(name.Literal)
+	} else {
+		parser.fail(token.RANGE)
+	}
+	decl.Span = parser.span(start)
+	return decl
 }
 
 func (parser *Parser) varDecl() []ast.VarDecl {
 	/*
 		variable_declaration:  ID(COMMA ID)*  COLON type_spec
 	*/
+	start := parser.CurToken.Pos
 	varNodes := make([]ast.VarNode, 0)
-	tok := parser.CurToken
-	varNodes = append(varNodes, ast.VarNode{tok, tok.Literal})
-	parser.eat(token.ID)
+	varNodes = append(varNodes, parser.variable().(ast.VarNode))
 
 	for parser.CurToken.Type == token.COMMA {
 		parser.eat(token.COMMA)
-		tok = parser.CurToken
-		varNodes = append(varNodes, ast.VarNode{tok, tok.Literal})
-		parser.eat(token.ID)
+		varNodes = append(varNodes, parser.variable().(ast.VarNode))
 	}
 
 	parser.eat(token.COLON)
 	typeSpec := parser.typeSpec()
 	decls := make([]ast.VarDecl, 0)
 	for _, elem := range varNodes {
-		decls = append(decls, ast.VarDecl{Node: elem, Type: typeSpec})
+		decls = append(decls, ast.VarDecl{Span: parser.span(start), Node: elem, Type: typeSpec})
 	}
 	return decls
 }
@@ -136,29 +436,46 @@ func (parser *Parser) typeSpec() token.Type {
 	/*
 		type_spec : INTEGER
 					| REAL
+					| CHAR
+					| STRING
+					| BOOLEAN
+					| ID
+
+		a declared type is named as it is written
 	*/
 
 	curType := parser.CurToken.Type
-	if parser.CurToken.Type == token.REAL {
-		parser.eat(token.REAL)
-
+	if curType == token.ID {
+		name := parser.CurToken.Literal
+		parser.eat(token.ID)
+		return token.Type(name)
 	}
-	if parser.CurToken.Type == token.INTEGER {
-		parser.eat(token.INTEGER)
+	if !parser.isBuiltinType() {
+		parser.fail("type")
 	}
+	parser.eat(curType)
 	return curType
 
 }
 
+//isBuiltinType reports whether the current token names a builtin type,
+//the INTEGER and REAL numbers have the token type of their type name
+func (parser *Parser) isBuiltinType() bool {
+	tok := parser.CurToken
+	return isInSlice(tok.Type, []token.Type{token.INTEGER, token.REAL, token.CHAR, token.STRING, token.BOOLEAN}) &&
+		token.Canonical(tok.Literal) == string(tok.Type)
+}
+
 func (parser *Parser) comStatement() ast.Compound {
 	/*
 		compound_statement: BEGIN statement_list END
 	*/
+	start := parser.CurToken.Pos
 	parser.eat(token.BEGIN)
 	comStatement := parser.statementList()
-	parser.eat(token.END)
+	parser.expect(token.END)
 
-	root := ast.Compound{}
+	root := ast.Compound{Span: parser.span(start)}
 	for _, st := range comStatement {
 		root.Children = append(root.Children, st)
 	}
@@ -172,41 +489,243 @@ func (parser *Parser) statementList() []ast.Expr {
 	*/
 
 	stList := make([]ast.Expr, 0)
-	st := parser.statement()
-	stList = append(stList, st)
-	for parser.CurToken.Type == token.SEMI {
-		parser.eat(token.SEMI)
-		res := parser.statement()
-		stList = append(stList, res)
-	}
+	for {
+		var st ast.Expr
+		if parser.guard(func() { st = parser.statement() }) {
+			stList = append(stList, st)
+		} else {
+			parser.synchronize()
+		}
 
-	return stList
+		switch {
+		case parser.CurToken.Type == token.SEMI:
+			parser.eat(token.SEMI)
+		case isInSlice(parser.CurToken.Type, statementStart):
+			// a missing semicolon, the next statement is parsed as if it was there
+			parser.error(token.SEMI)
+		default:
+			return stList
+		}
+	}
 }
 
+//statementStart holds the tokens a statement other than the empty one starts with
+var statementStart = []token.Type{token.BEGIN, token.IF, token.WHILE, token.REPEAT, token.FOR, token.CASE, token.ID}
+
 func (parser *Parser) statement() ast.Expr {
 	/*
 	    statement : compound_statement
+	   				| if_statement
+	   				| while_statement
+	   				| repeat_statement
+	   				| for_statement
+	   				| case_statement
 	   				| assignment_statement
+	   				| proccall_statement
 	   		 		| empty
 	*/
 	var st ast.Statement
 	if parser.CurToken.Type == token.BEGIN {
 		st.Statement = parser.comStatement()
-	} else if parser.CurToken.Type == token.ID {
+	} else if parser.CurToken.Type == token.IF {
+		st.Statement = parser.ifStatement()
+	} else if parser.CurToken.Type == token.WHILE {
+		st.Statement = parser.whileStatement()
+	} else if parser.CurToken.Type == token.REPEAT {
+		st.Statement = parser.repeatStatement()
+	} else if parser.CurToken.Type == token.FOR {
+		st.Statement = parser.forStatement()
+	} else if parser.CurToken.Type == token.CASE {
+		st.Statement = parser.caseStatement()
+	} else if parser.CurToken.Type == token.ID && parser.peek().Type == token.ASSIGN {
 		st.Statement = parser.assignmentStatement()
+	} else if parser.CurToken.Type == token.ID {
+		st.Statement = parser.procCallStatement()
 	} else {
 		st.Statement = parser.empty()
 	}
+	st.Span = st.Statement.GetSpan()
 	return st
 }
 
+func (parser *Parser) ifStatement() ast.Expr {
+	/*
+		if_statement : IF expr THEN statement (ELSE statement)?
+
+		an ELSE always belongs to the nearest IF
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.IF)
+	cond := parser.expr()
+	parser.eat(token.THEN)
+	res := ast.IfStatement{Cond: cond, Then: parser.statement()}
+	if parser.CurToken.Type == token.ELSE {
+		parser.eat(token.ELSE)
+		res.Else = parser.statement()
+	}
+	res.Span = parser.span(start)
+	return res
+}
+
+func (parser *Parser) whileStatement() ast.Expr {
+	/*
+		while_statement : WHILE expr DO statement
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.WHILE)
+	cond := parser.expr()
+	parser.eat(token.DO)
+	body := parser.statement()
+	return ast.WhileStatement{Span: parser.span(start), Cond: cond, Body: body}
+}
+
+func (parser *Parser) repeatStatement() ast.Expr {
+	/*
+		repeat_statement : REPEAT statement_list UNTIL expr
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.REPEAT)
+	body := parser.statementList()
+	parser.eat(token.UNTIL)
+	cond := parser.expr()
+	return ast.RepeatStatement{Span: parser.span(start), Body: body, Cond: cond}
+}
+
+func (parser *Parser) forStatement() ast.Expr {
+	/*
+		for_statement : FOR variable ASSIGN expr (TO | DOWNTO) expr DO statement
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.FOR)
+	res := ast.ForStatement{}
+	res.Var = parser.variable().(ast.VarNode)
+	parser.eat(token.ASSIGN)
+	res.Start = parser.expr()
+	if parser.CurToken.Type == token.DOWNTO {
+		parser.eat(token.DOWNTO)
+		res.Down = true
+	} else {
+		parser.eat(token.TO)
+	}
+	res.Stop = parser.expr()
+	parser.eat(token.DO)
+	res.Body = parser.statement()
+	res.Span = parser.span(start)
+	return res
+}
+
+func (parser *Parser) caseStatement() ast.Expr {
+	/*
+		case_statement : CASE expr OF case_element (SEMI case_element)* SEMI?
+						 ((ELSE | OTHERWISE) statement_list)? END
+	*/
+	start := parser.CurToken.Pos
+	parser.eat(token.CASE)
+	res := ast.CaseStatement{Selector: parser.expr()}
+	parser.eat(token.OF)
+	res.Branches = append(res.Branches, parser.caseElement())
+	for parser.CurToken.Type == token.SEMI {
+		parser.eat(token.SEMI)
+		if isInSlice(parser.CurToken.Type, []token.Type{token.ELSE, token.OTHERWISE, token.END}) {
+			break
+		}
+		res.Branches = append(res.Branches, parser.caseElement())
+	}
+	if parser.CurToken.Type == token.ELSE || parser.CurToken.Type == token.OTHERWISE {
+		parser.eat(parser.CurToken.Type)
+		res.Else = parser.statementList()
+	}
+	parser.eat(token.END)
+	res.Span = parser.span(start)
+	return res
+}
+
+func (parser *Parser) caseElement() ast.CaseBranch {
+	/*
+		case_element : case_label (COMMA case_label)* COLON statement
+
+		case_label : expr (RANGE expr)?
+	*/
+	start := parser.CurToken.Pos
+	branch := ast.CaseBranch{}
+	for {
+		labelStart := parser.CurToken.Pos
+		label := ast.CaseLabel{Low: parser.expr()}
+		if parser.CurToken.Type == token.RANGE {
+			parser.eat(token.RANGE)
+			label.High = parser.expr()
+		}
+		label.Span = parser.span(labelStart)
+		branch.Labels = append(branch.Labels, label)
+		if parser.CurToken.Type != token.COMMA {
+			break
+		}
+		parser.eat(token.COMMA)
+	}
+	parser.eat(token.COLON)
+	branch.Body = parser.statement()
+	branch.Span = parser.span(start)
+	return branch
+}
+
+func (parser *Parser) procCallStatement() ast.Expr {
+	/*
+		proccall_statement : ID (LPAREN (expr (COMMA expr)*)? RPAREN)?
+	*/
+	tok := parser.CurToken
+	parser.eat(token.ID)
+	args := make([]ast.Expr, 0)
+	if parser.CurToken.Type == token.LPAREN {
+		args = parser.arguments()
+	}
+	return ast.ProcedureCall{Span: parser.span(tok.Pos), Tok: tok, Name: tok.Literal, Args: args}
+}
+
+func (parser *Parser) funcCallExpr() ast.Expr {
+	/*
+		funccall_expression : ID LPAREN (expr (COMMA expr)*)? RPAREN
+	*/
+	tok := parser.CurToken
+	parser.eat(token.ID)
+	args := parser.arguments()
+	return ast.FunctionCall{Span: parser.span(tok.Pos), Tok: tok, Name: tok.Literal, Args: args}
+}
+
+//arguments parses the actual parameters LPAREN (argument (COMMA argument)*)? RPAREN
+func (parser *Parser) arguments() []ast.Expr {
+	args := make([]ast.Expr, 0)
+	parser.eat(token.LPAREN)
+	if parser.CurToken.Type != token.RPAREN {
+		args = append(args, parser.argument())
+		for parser.CurToken.Type == token.COMMA {
+			parser.eat(token.COMMA)
+			args = append(args, parser.argument())
+		}
+	}
+	parser.eat(token.RPAREN)
+	return args
+}
+
+//argument parses an actual parameter, expr or the name of a builtin type as Low and High
+//take it, which is a VarNode like the name of a declared type
+func (parser *Parser) argument() ast.Expr {
+	tok := parser.CurToken
+	if !parser.isBuiltinType() {
+		return parser.expr()
+	}
+	parser.eat(tok.Type)
+	return ast.VarNode{Span: parser.span(tok.Pos), Tok: tok, Literal: tok.Literal}
+}
+
 //implements assignmentStatement
 func (parser *Parser) assignmentStatement() ast.Expr {
+	start := parser.CurToken.Pos
 	left := parser.variable()
 	op := parser.CurToken
 	parser.eat(token.ASSIGN)
 	right := parser.expr()
 	return ast.AssignStatement{
+		Span:  parser.span(start),
 		Left:  left.(ast.VarNode),
 		Op:    op,
 		Right: right,
@@ -214,45 +733,62 @@ func (parser *Parser) assignmentStatement() ast.Expr {
 }
 
 func (parser *Parser) empty() ast.Expr {
-	// tok := parser.CurToken
-	// for tok.Type ==
-	return ast.NoOp{}
+	pos := parser.CurToken.Pos
+	return ast.NoOp{Span: ast.Span{Start: pos, End: pos}}
 }
 
 //expr
 func (parser *Parser) expr() ast.Expr {
 	/*
-		expr:  term((PLUS|MINUS)term)*
+		expr: simple_expression ((EQ|NE|LT|LE|GT|GE) simple_expression)?
 	*/
-	left := parser.term()
-	for parser.CurToken.Type == token.PLUS || parser.CurToken.Type == token.MINUS {
+	start := parser.CurToken.Pos
+	left := parser.simpleExpr()
+	relOps := []token.Type{token.EQ, token.NE, token.LT, token.LE, token.GT, token.GE}
+	if isInSlice(parser.CurToken.Type, relOps) {
 		tok := parser.CurToken
-		if tok.Type == token.PLUS {
-			parser.eat(token.PLUS)
-			rnode := parser.term()
-			left = ast.BinNode{Left: left, Right: rnode, Tok: tok}
-		}
+		parser.eat(tok.Type)
+		rnode := parser.simpleExpr()
+		left = ast.BinNode{Span: parser.span(start), Left: left, Right: rnode, Tok: tok}
+	}
+	return left
+}
 
-		if tok.Type == token.MINUS {
-			parser.eat(token.MINUS)
-			rnode := parser.term()
-			left = ast.BinNode{Left: left, Right: rnode, Tok: tok}
-		}
+func (parser *Parser) simpleExpr() ast.Expr {
+	/*
+		simple_expression:  term((PLUS|MINUS|OR|XOR)term)*
+	*/
+	start := parser.CurToken.Pos
+	left := parser.term()
+	addOps := []token.Type{token.PLUS, token.MINUS, token.OR, token.XOR}
+	for isInSlice(parser.CurToken.Type, addOps) {
+		tok := parser.CurToken
+		parser.eat(tok.Type)
+		rnode := parser.term()
+		left = ast.BinNode{Span: parser.span(start), Left: left, Right: rnode, Tok: tok}
 	}
 
 	return left
 }
 
+// peek returns the token after the current one, without eating anything
+func (parser *Parser) peek() token.Token {
+	lexer := parser.Lexer
+	lexer.ErrorList = nil
+	lexer.Comments = nil
+	return nextToken(&lexer)
+}
+
 // eat function compare the current token type with the passed token
 // type and if they match then "eat" the current token
 // and assign the next token to the  parser's current_token,
-// otherwise raise an exception.
+// otherwise fail with a SyntaxError.
 func (parser *Parser) eat(tokenType token.Type) {
 	if parser.CurToken.Type == tokenType {
-		parser.CurToken = parser.Lexer.NextToken()
+		parser.PrevEnd = parser.CurToken.End
+		parser.CurToken = nextToken(&parser.Lexer)
 	} else {
-		log.Fatalf("type not match, cur is %+v and input  is %+v , position is %+v, curchar is %+v",
-			parser.CurToken, tokenType, parser.Lexer.Pos, string(parser.Lexer.CurChar))
+		parser.fail(tokenType)
 	}
 }
 
@@ -260,31 +796,60 @@ func (parser *Parser) factor() ast.Expr {
 	/*
 		factor :  PLUS factor
 				| MINUS factor
+				| NOT factor
+				| TRUE
+				| FALSE
 				| REAL
 				| INTEGER
+				| STRING_CONST
+				| CHAR_CONST
 				| Lparenthesized expr Rparenthesized
+				| funccall_expression
 				| variable
 
 	*/
 	tok := parser.CurToken
-	if tok.Type == token.INTEGER {
+	// the type keywords INTEGER and REAL are no number literals
+	if tok.Type == token.INTEGER && !parser.isBuiltinType() {
 		parser.eat(token.INTEGER)
 		res := ast.NumNode{
+			Span:  parser.span(tok.Pos),
 			Tok:   tok,
 			Value: tok.Literal,
 		}
 		return res
 	}
 
-	if tok.Type == token.REAL {
+	if tok.Type == token.REAL && !parser.isBuiltinType() {
 		parser.eat(token.REAL)
 		res := ast.NumNode{
+			Span:  parser.span(tok.Pos),
 			Tok:   tok,
 			Value: tok.Literal,
 		}
 		return res
 	}
 
+	if tok.Type == token.STRING_CONST || tok.Type == token.CHAR_CONST {
+		parser.eat(tok.Type)
+		res := ast.StringNode{
+			Span:  parser.span(tok.Pos),
+			Tok:   tok,
+			Value: tok.Literal,
+		}
+		return res
+	}
+
+	if tok.Type == token.TRUE || tok.Type == token.FALSE {
+		parser.eat(tok.Type)
+		res := ast.BoolNode{
+			Span:  parser.span(tok.Pos),
+			Tok:   tok,
+			Value: tok.Type == token.TRUE,
+		}
+		return res
+	}
+
 	if tok.Type == token.LPAREN {
 		parser.eat(token.LPAREN)
 		res := parser.expr()
@@ -296,26 +861,44 @@ func (parser *Parser) factor() ast.Expr {
 		parser.eat(token.MINUS)
 		expr := parser.factor()
 		res := ast.Unary{
+			Span: parser.span(tok.Pos),
 			Op:   token.MINUS,
 			Expr: expr}
 
 		return res
 	}
 
+	if tok.Type == token.NOT {
+		parser.eat(token.NOT)
+		expr := parser.factor()
+		res := ast.Unary{
+			Span: parser.span(tok.Pos),
+			Op:   token.NOT,
+			Expr: expr,
+		}
+
+		return res
+	}
+
 	if tok.Type == token.PLUS {
 		parser.eat(token.PLUS)
 		expr := parser.factor()
 		res := ast.Unary{
+			Span: parser.span(tok.Pos),
 			Op:   token.PLUS,
 			Expr: expr,
 		}
 
 		return res
 	}
+	if tok.Type == token.ID && parser.peek().Type == token.LPAREN {
+		return parser.funcCallExpr()
+	}
 	if tok.Type == token.ID {
 		res := parser.variable()
 		return res
 	}
+	parser.fail("expression")
 	return nil
 }
 
@@ -329,20 +912,15 @@ func isInSlice(a token.Type, list []token.Type) bool {
 }
 func (parser *Parser) term() ast.Expr {
 	// context free grammar
-	// term : factor ((MUL | DIV)factor)*
+	// term : factor ((MUL | INTEGER_DIV | REAL_DIV | MOD | AND)factor)*
+	start := parser.CurToken.Pos
 	left := parser.factor()
-	for parser.CurToken.Type == token.MUL || parser.CurToken.Type == token.DIV {
+	mulOps := []token.Type{token.MUL, token.INTEGER_DIV, token.REAL_DIV, token.MOD, token.AND}
+	for isInSlice(parser.CurToken.Type, mulOps) {
 		tok := parser.CurToken
-		if parser.CurToken.Type == token.DIV {
-			parser.eat(token.DIV)
-			right := parser.factor()
-			left = ast.BinNode{Left: left, Right: right, Tok: tok}
-		}
-		if parser.CurToken.Type == token.MUL {
-			parser.eat(token.MUL)
-			right := parser.factor()
-			left = ast.BinNode{Left: left, Right: right, Tok: tok}
-		}
+		parser.eat(tok.Type)
+		right := parser.factor()
+		left = ast.BinNode{Span: parser.span(start), Left: left, Right: right, Tok: tok}
 	}
 	return left
 }
@@ -352,9 +930,11 @@ func (parser *Parser) variable() ast.Expr {
 	if tok.Type == token.ID {
 		parser.eat(token.ID)
 		res := ast.VarNode{
+			Span:    parser.span(tok.Pos),
 			Tok:     tok,
 			Literal: tok.Literal}
 		return res
 	}
+	parser.fail(token.ID)
 	return nil
 }
@@ -0,0 +1,312 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/lexer"
+	"pascal_in_go/token"
+	"testing"
+)
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected token.Type
+		actual   token.Type
+		pos      string
+	}{
+		{"program P;\nbegin\n  x := 1\n  y := 2\nend.", token.SEMI, token.ID, "4:3"},
+		{"program P;\nvar x : integer\nbegin\nend.", token.SEMI, token.BEGIN, "3:1"},
+		{"program P;\nvar x : 5;\nbegin\nend.", "type", token.INTEGER, "2:9"},
+		{"program P;\nbegin\n  x := 1 + ;\nend.", "expression", token.SEMI, "3:12"},
+		{"program P;\nbegin\nend", token.DOT, token.EOF, "3:4"},
+	}
+	for _, test := range tests {
+		parser := NewParser(lexer.NewLexer(test.text))
+		_, err := parser.Program()
+		var syntaxErr *diagnostics.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("error is %+v; expected a SyntaxError, text is %s\n ", err, test.text)
+		}
+		if syntaxErr.Expected != test.expected || syntaxErr.Actual.Type != test.actual || syntaxErr.Span.Start.String() != test.pos {
+			t.Errorf("error is %+v; expected %s at %s instead of %s, text is %s\n ",
+				syntaxErr, test.expected, test.pos, test.actual, test.text)
+		}
+	}
+}
+
+func TestProgram(t *testing.T) {
+	parser := NewParser(lexer.NewLexer("program P;\nbegin\n  x := 1\nend."))
+	tree, err := parser.Program()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none\n ", err)
+	}
+	if tree.ToStr() == "" || tree.GetSpan().End.String() != "4:5" {
+		t.Errorf("tree is %+v; expected the whole program\n ", tree)
+	}
+	// no declarations, the section is empty at the BEGIN
+	decl := tree.(ast.Program).Block.Decl
+	if decl.Start.String() != "2:1" || decl.End != decl.Start {
+		t.Errorf("span of declarations is %+v; expected empty at 2:1\n ", decl.Span)
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	text := `program P;
+var a : integer;
+    b : 5;
+    c : integer;
+
+procedure Q(x : );
+var d : integer;
+begin
+  d := 1
+end;
+
+procedure R;
+  a := 1
+end;
+
+begin
+  a := 1 + ;
+  a := 2
+  c := (3;
+  if a then begin a := ) end;
+  c := 4
+end.`
+	expect := []string{
+		"3:9: type not match, cur is INTEGER \"5\" and expected is type",
+		"6:17: type not match, cur is RPAREN \")\" and expected is type",
+		"13:3: type not match, cur is ID \"a\" and expected is BEGIN",
+		"17:12: type not match, cur is SEMI \";\" and expected is expression",
+		"19:3: type not match, cur is ID \"c\" and expected is SEMI",
+		"19:10: type not match, cur is SEMI \";\" and expected is RPAREN",
+		"20:24: type not match, cur is RPAREN \")\" and expected is expression",
+	}
+	parser := NewParser(lexer.NewLexer(text))
+	tree, err := parser.Program()
+	if len(parser.ErrorList) != len(expect) {
+		t.Fatalf("errors are %+v; expected  %+v\n ", parser.ErrorList, expect)
+	}
+	for i, want := range expect {
+		if parser.ErrorList[i].Error() != want {
+			t.Errorf("error is %q; expected  %q\n ", parser.ErrorList[i].Error(), want)
+		}
+	}
+	var syntaxErr *diagnostics.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr != parser.ErrorList[0] {
+		t.Errorf("error is %+v; expected the first syntax error\n ", err)
+	}
+
+	program := tree.(ast.Program)
+	if names := len(program.Block.Decl.VarDeclList); names != 2 {
+		t.Errorf("%d variables are declared; expected a and c\n ", names)
+	}
+	// R lacks BEGIN, its body is skipped and the errors after it are reported
+	if procs := program.Block.Decl.ProceDeclList; len(procs) != 2 || procs[0].Name != "Q" || len(procs[0].Block.Compound.Children) != 1 || procs[1].Name != "R" {
+		t.Errorf("procedures are %+v; expected Q with its body and R\n ", procs)
+	}
+	// the statements parsed are kept, the two with errors are dropped
+	if n := len(program.Block.Compound.Children); n != 3 {
+		t.Errorf("%d statements are parsed; expected 3\n ", n)
+	}
+	// the declarations are kept when the BEGIN of the program is missing
+	tree, _ = NewParser(lexer.NewLexer("program P;\nvar x : integer;\n    z : integer;\n  x := 1;\n  z := w\nend.")).Program()
+	if names := len(tree.(ast.Program).Block.Decl.VarDeclList); names != 2 {
+		t.Errorf("%d variables are declared; expected x and z\n ", names)
+	}
+}
+
+func TestConstDeclarations(t *testing.T) {
+	text := `program Consts;
+const Max = 100;
+      Pi2 = 2 * 3.14159;
+      Big : real = Max;
+var x : integer;
+const Name = 'pascal';
+begin
+  x := Max
+end.`
+	tree, err := NewParser(lexer.NewLexer(text)).Program()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none\n ", err)
+	}
+	consts := tree.(ast.Program).Block.Decl.ConstDeclList
+	expect := []struct {
+		name      string
+		typeName  token.Type
+		value     string
+		valueType string
+	}{
+		{"Max", "", "", "ast.NumNode"},
+		{"Pi2", "", "", "ast.BinNode"},
+		{"Big", token.REAL, "Max", "ast.VarNode"},
+		{"Name", "", "", "ast.StringNode"},
+	}
+	if len(consts) != len(expect) {
+		t.Fatalf("constants are %+v; expected  %+v\n ", consts, expect)
+	}
+	for i, want := range expect {
+		decl := consts[i]
+		valueType := fmt.Sprintf("%T", decl.Value)
+		if decl.Node.Literal != want.name || decl.Type != want.typeName || valueType != want.valueType {
+			t.Errorf("constant is %s : %s = %s; expected  %s : %s = %s\n ", decl.Node.Literal, decl.Type, valueType, want.name, want.typeName, want.valueType)
+		}
+		if want.value != "" && decl.Value.ToStr() != want.value {
+			t.Errorf("value of %s is %s; expected  %s\n ", want.name, decl.Value.ToStr(), want.value)
+		}
+	}
+}
+
+func TestTypeDeclarations(t *testing.T) {
+	text := `program Types;
+const Max = 100;
+type Index = 1..Max;
+     Score = Integer;
+     Letter = 'a'..'z';
+     Rank = Index;
+var i : Index;
+    s : Score;
+function F(r : Rank) : Letter;
+begin
+end;
+begin
+end.`
+	tree, err := NewParser(lexer.NewLexer(text)).Program()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none\n ", err)
+	}
+	block := tree.(ast.Program).Block
+	expect := []struct {
+		name     string
+		typeName token.Type
+		subrange bool
+	}{
+		{"Index", "", true},
+		{"Score", token.INTEGER, false},
+		{"Letter", "", true},
+		{"Rank", "Index", false},
+	}
+	types := block.Decl.TypeDeclList
+	if len(types) != len(expect) {
+		t.Fatalf("types are %+v; expected  %+v\n ", types, expect)
+	}
+	for i, want := range expect {
+		decl := types[i]
+		if decl.Node.Literal != want.name || decl.Type != want.typeName || (decl.Low != nil) != want.subrange {
+			t.Errorf("type is %+v; expected  %+v\n ", decl, want)
+		}
+	}
+	vars := block.Decl.VarDeclList
+	if vars[0].Type != "Index" || vars[1].Type != "Score" {
+		t.Errorf("variables are %+v; expected of type Index and Score\n ", vars)
+	}
+	function := block.Decl.FuncDeclList[0]
+	if function.Params[0].Type != "Rank" || function.ReturnType != "Letter" {
+		t.Errorf("function is %+v; expected a Rank parameter and a Letter result\n ", function)
+	}
+
+	_, err = NewParser(lexer.NewLexer("program P;\ntype T = 1 + 2;\nbegin\nend.")).Program()
+	var syntaxErr *diagnostics.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Expected != token.RANGE || syntaxErr.Span.Start.String() != "2:15" {
+		t.Errorf("error is %+v; expected RANGE at 2:15\n ", err)
+	}
+}
+
+func TestRangeChecksDirective(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"program P;\nbegin\nend.", false},
+		{"{$R+}\nprogram P;\nbegin\nend.", true},
+		{"{$RANGECHECKS ON}\nprogram P;\nbegin\nend.", true},
+		{"{$R+}{$R-}\nprogram P;\nbegin\nend.", false},
+	}
+	for _, test := range tests {
+		tree, err := NewParser(lexer.NewLexer(test.text)).Program()
+		if err != nil {
+			t.Fatalf("error is %+v; expected none\n ", err)
+		}
+		if got := tree.(ast.Program).RangeChecks; got != test.want {
+			t.Errorf("range checks are %v; expected  %v, text is %s\n ", got, test.want, test.text)
+		}
+	}
+}
+
+func TestEnumeratedTypes(t *testing.T) {
+	text := `program Enums;
+type Color = (Red, Green, Blue);
+     Warm = Red..Green;
+begin
+end.`
+	tree, err := NewParser(lexer.NewLexer(text)).Program()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none\n ", err)
+	}
+	types := tree.(ast.Program).Block.Decl.TypeDeclList
+	if len(types) != 2 {
+		t.Fatalf("types are %+v; expected Color and Warm\n ", types)
+	}
+	values := make([]string, 0)
+	for _, value := range types[0].Values {
+		values = append(values, value.Literal)
+	}
+	if fmt.Sprint(values) != "[Red Green Blue]" || types[0].Low != nil {
+		t.Errorf("type is %+v; expected the enumeration Red, Green, Blue\n ", types[0])
+	}
+	if types[1].Values != nil || types[1].Low == nil {
+		t.Errorf("type is %+v; expected a subrange\n ", types[1])
+	}
+
+	_, err = NewParser(lexer.NewLexer("program P;\ntype T = (A, 1);\nbegin\nend.")).Program()
+	var syntaxErr *diagnostics.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Expected != token.ID || syntaxErr.Span.Start.String() != "2:14" {
+		t.Errorf("error is %+v; expected ID at 2:14\n ", err)
+	}
+}
+
+func TestBuiltinTypeArguments(t *testing.T) {
+	text := `program Bounds;
+var c : char;
+    b : boolean;
+    i : integer;
+begin
+  c := High(Char);
+  b := Low(Boolean);
+  i := Low(Integer)
+end.`
+	tree, err := NewParser(lexer.NewLexer(text)).Program()
+	if err != nil {
+		t.Fatalf("error is %+v; expected none\n ", err)
+	}
+	statements := tree.(ast.Program).Block.Compound.Children
+	for i, want := range []string{"Char", "Boolean", "Integer"} {
+		call := statements[i].(ast.Statement).Statement.(ast.AssignStatement).Right.(ast.FunctionCall)
+		if arg, ok := call.Args[0].(ast.VarNode); !ok || arg.Literal != want {
+			t.Errorf("argument is %+v; expected the type name %s\n ", call.Args[0], want)
+		}
+	}
+
+	// a type keyword is no number literal
+	_, err = NewParser(lexer.NewLexer("program P;\nvar i : integer;\nbegin\n  i := Integer + 1\nend.")).Program()
+	var syntaxErr *diagnostics.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Span.Start.String() != "4:8" {
+		t.Errorf("error is %+v; expected a syntax error at 4:8\n ", err)
+	}
+}
+
+func TestIllegalCharacter(t *testing.T) {
+	text := "program P;\nvar x : integer;\nbegin\n  x := 1 @\nend."
+	_, err := NewParser(lexer.NewLexer(text)).Program()
+	var list ErrorList
+	if !errors.As(err, &list) || len(list) != 1 {
+		t.Fatalf("errors are %+v; expected one\n ", err)
+	}
+	var diag diagnostics.Diagnostic
+	if !errors.As(list[0], &diag) || diag.GetCode() != diagnostics.IllegalCharacter || diag.GetSpan().Start.String() != "4:10" {
+		t.Errorf("error is %+v; expected the illegal character at 4:10\n ", list[0])
+	}
+}
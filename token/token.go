@@ -1,36 +1,113 @@
 package token
 
+import (
+	"fmt"
+	"strings"
+)
+
 const (
 	INTEGER = "INTEGER"
 	REAL    = "REAL"
-	// REAL_DIV    = "REAL_DIV"
-	// INTEGER_DIV = "INTEGER_DIV"
-	PLUS      = "PLUS"
-	MINUS     = "MINUS"
-	DIV       = "DIV"
-	MUL       = "MUL"
-	EOF       = "EOF"
-	LPAREN    = "LPAREN"
-	RPAREN    = "RPAREN"
-	ILLEGAL   = "ILLEGAL"
-	END       = "END"
-	DOT       = "DOT"
-	ASSIGN    = "ASSGIGN"
-	SEMI      = "SEMI"
-	ID        = "ID"
-	BEGIN     = "BEGIN"
-	PROGRAM   = "PROGRAM"
-	VAR       = "VAR"
-	COMMA     = "COMMA"
-	COLON     = "COLON"
-	PROCEDURE = "PROCEDURE"
+	// REAL_DIV is / whose result is always REAL, INTEGER_DIV is DIV
+	REAL_DIV    = "REAL_DIV"
+	INTEGER_DIV = "INTEGER_DIV"
+	MOD         = "MOD"
+	PLUS        = "PLUS"
+	MINUS       = "MINUS"
+	MUL         = "MUL"
+	EOF         = "EOF"
+	LPAREN      = "LPAREN"
+	RPAREN      = "RPAREN"
+	ILLEGAL     = "ILLEGAL"
+	END         = "END"
+	DOT         = "DOT"
+	ASSIGN      = "ASSGIGN"
+	SEMI        = "SEMI"
+	ID          = "ID"
+	BEGIN       = "BEGIN"
+	PROGRAM     = "PROGRAM"
+	VAR         = "VAR"
+	COMMA       = "COMMA"
+	COLON       = "COLON"
+	PROCEDURE   = "PROCEDURE"
+	COMMENT     = "COMMENT"
+	STRING      = "STRING"
+	CHAR        = "CHAR"
+	// STRING_CONST and CHAR_CONST are the quoted literals, a CHAR_CONST holds exactly one character
+	STRING_CONST = "STRING_CONST"
+	CHAR_CONST   = "CHAR_CONST"
+	BOOLEAN      = "BOOLEAN"
+	TRUE         = "TRUE"
+	FALSE        = "FALSE"
+	EQ           = "EQ"
+	NE           = "NE"
+	LT           = "LT"
+	LE           = "LE"
+	GT           = "GT"
+	GE           = "GE"
+	AND          = "AND"
+	OR           = "OR"
+	XOR          = "XOR"
+	NOT          = "NOT"
+	IF           = "IF"
+	THEN         = "THEN"
+	ELSE         = "ELSE"
+	WHILE        = "WHILE"
+	DO           = "DO"
+	REPEAT       = "REPEAT"
+	UNTIL        = "UNTIL"
+	FOR          = "FOR"
+	TO           = "TO"
+	DOWNTO       = "DOWNTO"
+	CASE         = "CASE"
+	OF           = "OF"
+	OTHERWISE    = "OTHERWISE"
+	RANGE        = "RANGE"
+	CONST        = "CONST"
+	FUNCTION     = "FUNCTION"
+	TYPE         = "TYPE"
+	DIRECTIVE    = "DIRECTIVE"
 )
 
+//Canonical returns the spelling used to compare identifiers and reserved words,
+//which are case insensitive in pascal
+func Canonical(name string) string {
+	return strings.ToUpper(name)
+}
+
 //Type represents the type of a token
 type Type string
 
-// Token represents the atom token, with a type and literal value
+// Position represents a location in the source text.
+// Line and Column start at 1, Offset is the byte offset starting at 0
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
+// Span represents a range of the source text,
+// Start is the position of the first token and End the position after the last one
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// GetSpan returns the span of a node or a diagnostic
+func (span Span) GetSpan() Span {
+	return span
+}
+
+// Token represents the atom token, with a type and literal value.
+// Pos is the position of the first character of the token and
+// End is the position right after the last one
 type Token struct {
-	Type    Type   `json:"type"`
-	Literal string `json:"literal"`
+	Type    Type     `json:"type"`
+	Literal string   `json:"literal"`
+	Pos     Position `json:"pos"`
+	End     Position `json:"end"`
 }
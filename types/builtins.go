@@ -0,0 +1,164 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+)
+
+//visitBuiltinCall checks a call of a standard function and returns its result type, Ord, Succ
+//and Pred take an ordinal value and Low and High an ordinal type or a variable of one.
+//Succ and Pred of a constant beyond the values of an enumerated type are reported
+func (symtab *SymbolTable) visitBuiltinCall(name string, span ast.Span, args []ast.Expr) string {
+	if len(args) != 1 {
+		for _, arg := range args {
+			symtab.Visit(arg)
+		}
+		msg := fmt.Sprintf("wrong number of arguments to %s, got %d and expected 1", name, len(args))
+		symtab.addError(diagnostics.ArgumentCount, span, msg)
+		return ""
+	}
+	arg := args[0]
+	canon := token.Canonical(name)
+	var argType string
+	if typeName, ok := symtab.typeArgument(canon, arg); ok {
+		argType = typeName
+	} else {
+		argType = symtab.visitExpr(arg)
+	}
+	if argType == "" {
+		return ""
+	}
+	if !symtab.isOrdinal(argType) {
+		msg := fmt.Sprintf("argument of %s must be of an ordinal type, got %s", name, argType)
+		symtab.addError(diagnostics.TypeMismatch, arg.GetSpan(), msg)
+		return ""
+	}
+
+	switch canon {
+	case "ORD":
+		return token.INTEGER
+	case "SUCC", "PRED":
+		values, enum := symtab.enums[argType]
+		if !enum {
+			break
+		}
+		c, ok := symtab.evalConst(arg)
+		if !ok || c.Type != argType {
+			break
+		}
+		if v := c.Ordinal() + step(canon); v < 0 || v >= int64(len(values)) {
+			msg := fmt.Sprintf("%s(%s) is out of the values of %s", name, values[c.Ordinal()], argType)
+			symtab.addError(diagnostics.OutOfRange, span, msg)
+		}
+	}
+	return argType
+}
+
+//typeArgument returns the type named by the argument of Low or High, a declared or a builtin
+//type, ok is false when the function is another one or the argument is no type name
+func (symtab *SymbolTable) typeArgument(canon string, arg ast.Expr) (typeName string, ok bool) {
+	v, isVar := arg.(ast.VarNode)
+	if !isVar || canon != "LOW" && canon != "HIGH" {
+		return "", false
+	}
+	switch symbol := symtab.lookup(v.Literal).(type) {
+	case TypeSymbol:
+		return symbol.Base, true
+	case BuiltinTypeSymbol:
+		return symbol.Type, true
+	}
+	return "", false
+}
+
+//step returns how far Succ or Pred moves an ordinal number
+func step(canon string) int64 {
+	if canon == "PRED" {
+		return -1
+	}
+	return 1
+}
+
+//builtinConst computes a call of a standard function whose argument is a constant, Low and
+//High of a type or of a variable are constants too. Succ and Pred beyond the values of an
+//enumerated type give an unknown value, visitBuiltinCall reports them
+func (symtab *SymbolTable) builtinConst(t ast.FunctionCall) (Constant, bool) {
+	if len(t.Args) != 1 {
+		return Constant{}, false
+	}
+	canon := token.Canonical(t.Name)
+	arg := t.Args[0]
+	if canon == "LOW" || canon == "HIGH" {
+		typeName, rng, ok := symtab.declaredType(arg)
+		if !ok {
+			c, isConst := symtab.evalConst(arg)
+			if !isConst {
+				return Constant{}, false
+			}
+			typeName = c.Type
+		}
+		if !symtab.isOrdinal(typeName) {
+			return Constant{}, false
+		}
+		low, high := symtab.ordinalBounds(typeName, rng)
+		if canon == "LOW" {
+			return ordinalConst(low, typeName), true
+		}
+		return ordinalConst(high, typeName), true
+	}
+
+	c, ok := symtab.evalConst(arg)
+	if !ok || c.Type == "" {
+		return c, ok
+	}
+	if !symtab.isOrdinal(c.Type) {
+		return Constant{}, false
+	}
+	if canon == "ORD" {
+		return Constant{Type: token.INTEGER, Value: c.Ordinal()}, true
+	}
+	v := c.Ordinal() + step(canon)
+	if values, enum := symtab.enums[c.Type]; enum && (v < 0 || v >= int64(len(values))) {
+		return Constant{}, true
+	}
+	return ordinalConst(v, c.Type), true
+}
+
+//declaredType returns the type and the bounds of a subrange of the argument of Low or High
+//when it names a type or a variable, ok is false for any other expression
+func (symtab *SymbolTable) declaredType(arg ast.Expr) (typeName string, rng *Subrange, ok bool) {
+	v, isVar := arg.(ast.VarNode)
+	if !isVar {
+		return "", nil, false
+	}
+	switch symbol := symtab.lookup(v.Literal).(type) {
+	case TypeSymbol:
+		return symbol.Base, symbol.Range, true
+	case BuiltinTypeSymbol:
+		return symbol.Type, nil, true
+	case VarSymbol:
+		return symbol.Type, symbol.Range, true
+	case ParamSymbol:
+		return symbol.Type, symbol.Range, true
+	}
+	return "", nil, false
+}
+
+//ordinalBounds returns the least and the greatest ordinal number of an ordinal type,
+//those of rng for a subrange of it
+func (symtab *SymbolTable) ordinalBounds(typeName string, rng *Subrange) (low int64, high int64) {
+	if rng != nil {
+		return rng.Low, rng.High
+	}
+	switch typeName {
+	case token.INTEGER:
+		return math.MinInt64, math.MaxInt64
+	case token.CHAR:
+		return 0, 255
+	case token.BOOLEAN:
+		return 0, 1
+	}
+	return 0, int64(len(symtab.enums[typeName]) - 1)
+}
@@ -0,0 +1,244 @@
+package types
+
+import (
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+	"strconv"
+	"strings"
+)
+
+//Constant is the value of a constant expression computed by the analyzer, Value is an int64
+//for an INTEGER or the ordinal number of a value of an enumerated type, a float64 for a REAL,
+//a bool for a BOOLEAN and a string for a CHAR or STRING.
+//Type is empty when the value is unknown because of an error already reported
+type Constant struct {
+	Type  string
+	Value interface{}
+}
+
+//Ordinal returns the ordinal number of an INTEGER, CHAR, BOOLEAN or enumerated constant
+func (c Constant) Ordinal() int64 {
+	switch v := c.Value.(type) {
+	case int64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+	case string:
+		if c.Type == token.CHAR && len(v) > 0 {
+			return int64(v[0])
+		}
+	}
+	return 0
+}
+
+//real returns a numeric constant as a float64
+func (c Constant) real() float64 {
+	switch v := c.Value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+//convert returns the constant as held by a constant of the type, an INTEGER becomes
+//a REAL and a CHAR a STRING
+func (c Constant) convert(typeName string) Constant {
+	if typeName == token.REAL && c.Type == token.INTEGER {
+		return Constant{Type: token.REAL, Value: c.real()}
+	}
+	if typeName == token.STRING && c.Type == token.CHAR {
+		return Constant{Type: token.STRING, Value: c.Value}
+	}
+	return c
+}
+
+//ordinalConst returns the constant of the ordinal type with the ordinal number
+func ordinalConst(value int64, typeName string) Constant {
+	switch typeName {
+	case token.CHAR:
+		return Constant{Type: typeName, Value: string([]byte{byte(value)})}
+	case token.BOOLEAN:
+		return Constant{Type: typeName, Value: value != 0}
+	}
+	return Constant{Type: typeName, Value: value}
+}
+
+//evalConst computes a constant expression of literals, constants and calls of the standard
+//functions, ok is false when node is no constant expression. A division by zero is reported
+//and gives an unknown value
+func (symtab *SymbolTable) evalConst(node ast.Expr) (c Constant, ok bool) {
+	switch t := node.(type) {
+	case ast.NumNode:
+		if t.Tok.Type == token.INTEGER {
+			if value, err := strconv.ParseInt(t.Value, 10, 64); err == nil {
+				return Constant{Type: token.INTEGER, Value: value}, true
+			}
+		}
+		value, err := strconv.ParseFloat(t.Value, 64)
+		return Constant{Type: token.REAL, Value: value}, err == nil
+	case ast.StringNode:
+		if t.Tok.Type == token.CHAR_CONST {
+			return Constant{Type: token.CHAR, Value: t.Value}, true
+		}
+		return Constant{Type: token.STRING, Value: t.Value}, true
+	case ast.BoolNode:
+		return Constant{Type: token.BOOLEAN, Value: t.Value}, true
+	case ast.VarNode:
+		if constSymbol, isConst := symtab.lookup(t.Literal).(ConstSymbol); isConst {
+			return constSymbol.Value, true
+		}
+	case ast.FunctionCall:
+		if _, isBuiltin := symtab.lookup(t.Name).(BuiltinRoutineSymbol); isBuiltin {
+			return symtab.builtinConst(t)
+		}
+	case ast.Unary:
+		operand, ok := symtab.evalConst(t.Expr)
+		if ok && operand.Type == "" {
+			return operand, true
+		}
+		if ok {
+			return unaryConst(t.Op, operand)
+		}
+	case ast.BinNode:
+		left, lok := symtab.evalConst(t.Left)
+		right, rok := symtab.evalConst(t.Right)
+		if !lok || !rok {
+			break
+		}
+		if left.Type == "" || right.Type == "" {
+			return Constant{}, true
+		}
+		divisor := t.Tok.Type == token.REAL_DIV || t.Tok.Type == token.INTEGER_DIV || t.Tok.Type == token.MOD
+		if divisor && isNumeric(right.Type) && right.real() == 0 {
+			symtab.addError(diagnostics.InvalidConstant, t.Span, "division by zero in constant expression")
+			return Constant{}, true
+		}
+		return binaryConst(t.Tok.Type, left, right)
+	}
+	return Constant{}, false
+}
+
+func unaryConst(op string, operand Constant) (Constant, bool) {
+	switch v := operand.Value.(type) {
+	case int64:
+		// a value of an enumerated type has no operators
+		if operand.Type != token.INTEGER {
+			break
+		}
+		switch op {
+		case token.MINUS:
+			return Constant{Type: token.INTEGER, Value: -v}, true
+		case token.PLUS:
+			return operand, true
+		case token.NOT:
+			return Constant{Type: token.INTEGER, Value: ^v}, true
+		}
+	case float64:
+		switch op {
+		case token.MINUS:
+			return Constant{Type: token.REAL, Value: -v}, true
+		case token.PLUS:
+			return operand, true
+		}
+	case bool:
+		if op == token.NOT {
+			return Constant{Type: token.BOOLEAN, Value: !v}, true
+		}
+	}
+	return Constant{}, false
+}
+
+//binaryConst applies an operator to constants the way the interpreter does at runtime,
+//ok is false when the operator is not applicable to them
+func binaryConst(op token.Type, left Constant, right Constant) (Constant, bool) {
+	switch op {
+	case token.EQ, token.NE, token.LT, token.LE, token.GT, token.GE:
+		if !comparable(left.Type, right.Type) {
+			return Constant{}, false
+		}
+		cmp := compareConst(left, right)
+		result := map[token.Type]bool{
+			token.EQ: cmp == 0, token.NE: cmp != 0, token.LT: cmp < 0,
+			token.LE: cmp <= 0, token.GT: cmp > 0, token.GE: cmp >= 0,
+		}[op]
+		return Constant{Type: token.BOOLEAN, Value: result}, true
+	case token.AND, token.OR, token.XOR:
+		l, lok := left.Value.(bool)
+		r, rok := right.Value.(bool)
+		if lok && rok {
+			result := map[token.Type]bool{token.AND: l && r, token.OR: l || r, token.XOR: l != r}[op]
+			return Constant{Type: token.BOOLEAN, Value: result}, true
+		}
+		li, lok := left.Value.(int64)
+		ri, rok := right.Value.(int64)
+		if lok && rok && left.Type == token.INTEGER && right.Type == token.INTEGER {
+			result := map[token.Type]int64{token.AND: li & ri, token.OR: li | ri, token.XOR: li ^ ri}[op]
+			return Constant{Type: token.INTEGER, Value: result}, true
+		}
+		return Constant{}, false
+	}
+
+	if op == token.PLUS && isString(left.Type) && isString(right.Type) {
+		return Constant{Type: token.STRING, Value: left.Value.(string) + right.Value.(string)}, true
+	}
+	if !isNumeric(left.Type) || !isNumeric(right.Type) {
+		return Constant{}, false
+	}
+	l, lok := left.Value.(int64)
+	r, rok := right.Value.(int64)
+	if lok && rok {
+		switch op {
+		case token.PLUS:
+			return Constant{Type: token.INTEGER, Value: l + r}, true
+		case token.MINUS:
+			return Constant{Type: token.INTEGER, Value: l - r}, true
+		case token.MUL:
+			return Constant{Type: token.INTEGER, Value: l * r}, true
+		case token.INTEGER_DIV:
+			return Constant{Type: token.INTEGER, Value: l / r}, true
+		case token.MOD:
+			return Constant{Type: token.INTEGER, Value: l % r}, true
+		}
+	}
+	switch op {
+	case token.PLUS:
+		return Constant{Type: token.REAL, Value: left.real() + right.real()}, true
+	case token.MINUS:
+		return Constant{Type: token.REAL, Value: left.real() - right.real()}, true
+	case token.MUL:
+		return Constant{Type: token.REAL, Value: left.real() * right.real()}, true
+	case token.REAL_DIV:
+		return Constant{Type: token.REAL, Value: left.real() / right.real()}, true
+	}
+	return Constant{}, false
+}
+
+//compareConst returns -1, 0 or 1 when left is less than, equal to or greater than right
+func compareConst(left Constant, right Constant) int {
+	if isString(left.Type) {
+		return strings.Compare(left.Value.(string), right.Value.(string))
+	}
+	if left.Type == token.REAL || right.Type == token.REAL {
+		l, r := left.real(), right.real()
+		if l < r {
+			return -1
+		}
+		if l > r {
+			return 1
+		}
+		return 0
+	}
+	l, r := left.Ordinal(), right.Ordinal()
+	if l < r {
+		return -1
+	}
+	if l > r {
+		return 1
+	}
+	return 0
+}
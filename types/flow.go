@@ -0,0 +1,242 @@
+package types
+
+import (
+	"fmt"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+)
+
+//assignedSet holds the canonical names of the local variables definitely assigned
+//at some point of a block
+type assignedSet map[string]bool
+
+func (set assignedSet) copy() assignedSet {
+	dup := make(assignedSet, len(set))
+	for name := range set {
+		dup[name] = true
+	}
+	return dup
+}
+
+//meet returns the variables assigned on both paths joining, a nil set is no path yet
+func meet(set assignedSet, other assignedSet) assignedSet {
+	if set == nil {
+		return other
+	}
+	both := make(assignedSet)
+	for name := range set {
+		if other[name] {
+			both[name] = true
+		}
+	}
+	return both
+}
+
+//flow is the definite-assignment analysis of the body of one block, it follows every
+//path through the statements keeping the locals assigned so far
+type flow struct {
+	symtab *SymbolTable
+	scope  *ScopedSymbolTable
+	// locals holds the variables declared in the block
+	locals map[string]bool
+	// routineWrites holds the variables of the scope the nested routines assign,
+	// any call may run one of them so they count as assigned after it
+	routineWrites map[string]bool
+	// warned holds the locals already reported as read before they are assigned
+	warned map[string]bool
+}
+
+//checkFlow warns about the variables of the block in the current scope which may be read
+//before they are assigned, then about those declared but never used or assigned but never read.
+//It runs once the nested routines are checked, so their reads and writes are known
+func (symtab *SymbolTable) checkFlow(t ast.Block) {
+	scope := symtab.CurrentScope
+	f := &flow{
+		symtab:        symtab,
+		scope:         scope,
+		locals:        make(map[string]bool),
+		routineWrites: make(map[string]bool),
+		warned:        make(map[string]bool),
+	}
+	for _, decl := range t.Decl.VarDeclList {
+		name := token.Canonical(decl.Node.Literal)
+		if _, ok := scope.Symbols[name].(VarSymbol); ok {
+			f.locals[name] = true
+		}
+	}
+	for name := range scope.writes {
+		f.routineWrites[name] = true
+	}
+	f.statement(t.Compound, make(assignedSet))
+
+	warned := make(map[string]bool)
+	for _, decl := range t.Decl.VarDeclList {
+		name := token.Canonical(decl.Node.Literal)
+		if !f.locals[name] || warned[name] {
+			continue
+		}
+		warned[name] = true
+		switch {
+		case !scope.reads[name] && !scope.writes[name]:
+			msg := fmt.Sprintf("variable %s is declared but never used", decl.Node.Literal)
+			symtab.addWarning(diagnostics.UnusedVariable, decl.Node.Span, msg)
+		case !scope.reads[name]:
+			msg := fmt.Sprintf("variable %s is assigned but never read", decl.Node.Literal)
+			symtab.addWarning(diagnostics.UnreadVariable, decl.Node.Span, msg)
+		}
+	}
+}
+
+//statement walks a statement with the locals assigned before it and returns those
+//assigned after it, a branch is walked with a copy of the set
+func (f *flow) statement(node ast.Expr, assigned assignedSet) assignedSet {
+	switch t := node.(type) {
+	case ast.Statement:
+		return f.statement(t.Statement, assigned)
+	case ast.Compound:
+		for _, child := range t.Children {
+			assigned = f.statement(child, assigned)
+		}
+	case ast.AssignStatement:
+		f.expr(t.Right, assigned)
+		f.assign(t.Left.Literal, assigned)
+	case ast.IfStatement:
+		f.expr(t.Cond, assigned)
+		then := f.statement(t.Then, assigned.copy())
+		if t.Else == nil {
+			return meet(then, assigned)
+		}
+		return meet(then, f.statement(t.Else, assigned.copy()))
+	case ast.WhileStatement:
+		// the body may not run at all
+		f.expr(t.Cond, assigned)
+		f.statement(t.Body, assigned.copy())
+	case ast.RepeatStatement:
+		for _, st := range t.Body {
+			assigned = f.statement(st, assigned)
+		}
+		f.expr(t.Cond, assigned)
+	case ast.ForStatement:
+		f.expr(t.Start, assigned)
+		f.expr(t.Stop, assigned)
+		body := assigned.copy()
+		f.assign(t.Var.Literal, body)
+		f.use(t.Var.Literal)
+		f.statement(t.Body, body)
+		// the control variable is undefined after the loop
+		delete(assigned, token.Canonical(t.Var.Literal))
+	case ast.CaseStatement:
+		f.expr(t.Selector, assigned)
+		var after assignedSet
+		for _, branch := range t.Branches {
+			after = meet(after, f.statement(branch.Body, assigned.copy()))
+		}
+		if t.Else == nil {
+			return meet(after, assigned)
+		}
+		rest := assigned.copy()
+		for _, st := range t.Else {
+			rest = f.statement(st, rest)
+		}
+		return meet(after, rest)
+	case ast.ProcedureCall:
+		f.call(t.Name, t.Args, assigned)
+	}
+	return assigned
+}
+
+//expr walks an expression reading its variables
+func (f *flow) expr(node ast.Expr, assigned assignedSet) {
+	switch t := node.(type) {
+	case ast.VarNode:
+		f.read(t, assigned)
+	case ast.BinNode:
+		f.expr(t.Left, assigned)
+		f.expr(t.Right, assigned)
+	case ast.Unary:
+		f.expr(t.Expr, assigned)
+	case ast.FunctionCall:
+		f.call(t.Name, t.Args, assigned)
+	}
+}
+
+//read marks a variable read and warns when it is a local not assigned on every path
+//to the read, the name of a function stands for a call
+func (f *flow) read(t ast.VarNode, assigned assignedSet) {
+	scope := f.scope.scopeOf(t.Literal)
+	if scope == nil {
+		return
+	}
+	name := token.Canonical(t.Literal)
+	if _, ok := scope.Symbols[name].(FunctionSymbol); ok {
+		f.call(t.Literal, nil, assigned)
+		return
+	}
+	scope.reads[name] = true
+	if scope == f.scope && f.locals[name] && !assigned[name] && !f.warned[name] {
+		f.warned[name] = true
+		msg := fmt.Sprintf("variable %s may be read before it is assigned", t.Literal)
+		f.symtab.addWarning(diagnostics.ReadBeforeAssigned, t.Span, msg)
+	}
+}
+
+//use marks a variable read without checking it is assigned
+func (f *flow) use(name string) {
+	if scope := f.scope.scopeOf(name); scope != nil {
+		scope.reads[token.Canonical(name)] = true
+	}
+}
+
+//assign marks a variable assigned in the scope declaring it, a local is assigned from now on
+func (f *flow) assign(name string, assigned assignedSet) {
+	scope := f.scope.scopeOf(name)
+	if scope == nil {
+		return
+	}
+	canon := token.Canonical(name)
+	scope.writes[canon] = true
+	if scope == f.scope {
+		assigned[canon] = true
+	}
+}
+
+//call walks the arguments of a call, a variable passed to a VAR parameter may be read and
+//is assigned by the routine, and so are the variables the nested routines assign
+func (f *flow) call(name string, args []ast.Expr, assigned assignedSet) {
+	var params []ParamSymbol
+	if scope := f.scope.scopeOf(name); scope != nil {
+		switch routine := scope.Symbols[token.Canonical(name)].(type) {
+		case ProcedureSymbol:
+			params = routine.Params
+		case FunctionSymbol:
+			params = routine.Params
+		case BuiltinRoutineSymbol:
+			f.builtinCall(token.Canonical(name), args, assigned)
+			return
+		}
+	}
+	for i, arg := range args {
+		if v, ok := arg.(ast.VarNode); ok && i < len(params) && params[i].Mode == token.VAR {
+			f.use(v.Literal)
+			f.assign(v.Literal, assigned)
+			continue
+		}
+		f.expr(arg, assigned)
+	}
+	for name := range f.routineWrites {
+		assigned[name] = true
+	}
+}
+
+//builtinCall walks the arguments of a standard function, which assigns nothing,
+//Low and High do not read the variable they are given
+func (f *flow) builtinCall(canon string, args []ast.Expr, assigned assignedSet) {
+	for _, arg := range args {
+		if v, ok := arg.(ast.VarNode); ok && (canon == "LOW" || canon == "HIGH") {
+			f.use(v.Literal)
+			continue
+		}
+		f.expr(arg, assigned)
+	}
+}
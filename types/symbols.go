@@ -1,9 +1,11 @@
 package types
 
 import (
-	"errors"
 	"fmt"
 	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/token"
+	"strconv"
 )
 
 type Symbol interface {
@@ -15,9 +17,62 @@ type BuiltinTypeSymbol struct {
 	Name string
 	Type string
 }
+
+//VarSymbol is a variable, Type is the builtin type of its declared type
+//and Range the bounds of a subrange type or nil
 type VarSymbol struct {
+	Name  string
+	Type  string
+	Range *Subrange
+}
+
+//ProcedureSymbol holds the name and the formal parameters of a procedure
+type ProcedureSymbol struct {
+	Name   string
+	Params []ParamSymbol
+}
+
+//FunctionSymbol holds the name, the formal parameters and the result type of a function
+type FunctionSymbol struct {
+	Name       string
+	Params     []ParamSymbol
+	ReturnType string
+}
+
+//ParamSymbol represents a formal parameter, Mode is VAR, CONST or empty,
+//Type and Range are those of a VarSymbol
+type ParamSymbol struct {
+	Name  string
+	Type  string
+	Mode  string
+	Range *Subrange
+}
+
+//TypeSymbol is a type declared in a TYPE section, Base is the builtin type it stands for,
+//the name of an enumerated type for an enumeration, and Range the bounds of a subrange or nil
+type TypeSymbol struct {
+	Name  string
+	Base  string
+	Range *Subrange
+}
+
+//Subrange holds the bounds of a subrange type as ordinal numbers
+type Subrange struct {
+	Low  int64
+	High int64
+}
+
+//ConstSymbol is a constant, its Value is computed when it is declared
+type ConstSymbol struct {
+	Name  string
+	Type  string
+	Value Constant
+}
+
+//BuiltinRoutineSymbol is a standard function taking an argument of any ordinal type,
+//Ord, Succ, Pred, Low or High, see visitBuiltinCall
+type BuiltinRoutineSymbol struct {
 	Name string
-	Type string
 }
 
 func (bts BuiltinTypeSymbol) ShowName() string {
@@ -36,58 +91,353 @@ func (vs VarSymbol) ShowType() string {
 	return vs.Type
 }
 
+func (ps ProcedureSymbol) ShowName() string {
+	return ps.Name
+}
+
+func (ps ProcedureSymbol) ShowType() string {
+	return token.PROCEDURE
+}
+
+func (fs FunctionSymbol) ShowName() string {
+	return fs.Name
+}
+
+//ShowType of a function is its result type
+func (fs FunctionSymbol) ShowType() string {
+	return fs.ReturnType
+}
+
+func (ps ParamSymbol) ShowName() string {
+	return ps.Name
+}
+
+func (ps ParamSymbol) ShowType() string {
+	return ps.Type
+}
+
+func (ts TypeSymbol) ShowName() string {
+	return ts.Name
+}
+
+func (ts TypeSymbol) ShowType() string {
+	return ts.Base
+}
+
+func (cs ConstSymbol) ShowName() string {
+	return cs.Name
+}
+
+func (cs ConstSymbol) ShowType() string {
+	return cs.Type
+}
+
+func (brs BuiltinRoutineSymbol) ShowName() string {
+	return brs.Name
+}
+
+//ShowType of a standard function is empty, its result type depends on the argument
+func (brs BuiltinRoutineSymbol) ShowType() string {
+	return ""
+}
+
+//ScopedSymbolTable holds the symbols declared in one scope, the builtin scope is
+//at level 0, the program at level 1 and every procedure or function one level
+//deeper than the scope it is declared in
+type ScopedSymbolTable struct {
+	ScopeName      string
+	ScopeLevel     int
+	EnclosingScope *ScopedSymbolTable
+	Symbols        map[string]Symbol
+	// reads and writes hold the variables of the scope read and assigned so far, see checkFlow
+	reads  map[string]bool
+	writes map[string]bool
+}
+
+func NewScopedSymbolTable(name string, level int, enclosing *ScopedSymbolTable) *ScopedSymbolTable {
+	return &ScopedSymbolTable{
+		ScopeName:      name,
+		ScopeLevel:     level,
+		EnclosingScope: enclosing,
+		Symbols:        make(map[string]Symbol),
+		reads:          make(map[string]bool),
+		writes:         make(map[string]bool),
+	}
+}
+
+func (scope *ScopedSymbolTable) Define(symbol Symbol) {
+	name := token.Canonical(symbol.ShowName())
+	scope.Symbols[name] = symbol
+}
+
+//Lookup finds name in this scope and then in the enclosing ones,
+//with currentScopeOnly the enclosing scopes are not searched
+func (scope *ScopedSymbolTable) Lookup(name string, currentScopeOnly bool) Symbol {
+	symbol, ok := scope.Symbols[token.Canonical(name)]
+	if ok {
+		return symbol
+	}
+	if currentScopeOnly || scope.EnclosingScope == nil {
+		return nil
+	}
+	return scope.EnclosingScope.Lookup(name, false)
+}
+
+//scopeOf returns the scope name is declared in, this one or an enclosing one, or nil
+func (scope *ScopedSymbolTable) scopeOf(name string) *ScopedSymbolTable {
+	canon := token.Canonical(name)
+	for ; scope != nil; scope = scope.EnclosingScope {
+		if _, ok := scope.Symbols[canon]; ok {
+			return scope
+		}
+	}
+	return nil
+}
+
+//SymbolTable is the semantic analyzer, it walks the tree keeping the scope it is in
 type SymbolTable struct {
-	Symbols   map[string]Symbol
+	CurrentScope *ScopedSymbolTable
+	// ErrorList holds the errors and the warnings found, each a *diagnostics.SemanticError
 	ErrorList []error
+	// Types holds the type name computed for each expression by its span, see TypeOf
+	Types map[ast.Span]string
+	// forVars holds the control variables of the enclosing FOR statements
+	forVars map[string]bool
+	// mode is the compiler mode of the program, see ast.Program
+	mode string
+	// enums holds the names of the values of each enumerated type in order
+	enums map[string][]string
 }
 
-func (symtab *SymbolTable) define(symbol Symbol) {
-	switch t := symbol.(type) {
-	case BuiltinTypeSymbol:
-		fmt.Printf("BuiltinSymbol : %+v\n", t)
-	case VarSymbol:
-		fmt.Printf("VarSymbol : %+v\n", t)
-
+//NewSymbolTable returns an analyzer in the builtin scope, see InitBuiltins
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{
+		CurrentScope: NewScopedSymbolTable("builtins", 0, nil),
+		ErrorList:    make([]error, 0),
+		Types:        make(map[ast.Span]string),
+		enums:        make(map[string][]string),
 	}
-	name := symbol.ShowName()
-	symtab.Symbols[name] = symbol
+}
+
+func (symtab *SymbolTable) define(symbol Symbol) {
+	symtab.CurrentScope.Define(symbol)
 }
 
 func (symtab *SymbolTable) lookup(name string) Symbol {
-	fmt.Println("lookup: ", name)
-	symbol := symtab.Symbols[name]
-	return symbol
+	return symtab.CurrentScope.Lookup(name, false)
+}
+
+//lookupLocal finds name in the current scope only
+func (symtab *SymbolTable) lookupLocal(name string) Symbol {
+	return symtab.CurrentScope.Lookup(name, true)
+}
+
+//enterScope opens the scope of a routine or the program nested in the current one
+func (symtab *SymbolTable) enterScope(name string) {
+	symtab.CurrentScope = NewScopedSymbolTable(name, symtab.CurrentScope.ScopeLevel+1, symtab.CurrentScope)
 }
 
+func (symtab *SymbolTable) leaveScope() {
+	symtab.CurrentScope = symtab.CurrentScope.EnclosingScope
+}
+
+//InitBuiltins defines the builtin types and the standard functions in the current scope,
+//the builtin one right after NewSymbolTable
 func (symtab *SymbolTable) InitBuiltins() {
-	symtab.define(BuiltinTypeSymbol{Type: "INTEGER"})
-	symtab.define(BuiltinTypeSymbol{Type: "REAL"})
+	symtab.define(BuiltinTypeSymbol{Name: "INTEGER", Type: "INTEGER"})
+	symtab.define(BuiltinTypeSymbol{Name: "REAL", Type: "REAL"})
+	symtab.define(BuiltinTypeSymbol{Name: "CHAR", Type: "CHAR"})
+	symtab.define(BuiltinTypeSymbol{Name: "STRING", Type: "STRING"})
+	symtab.define(BuiltinTypeSymbol{Name: "BOOLEAN", Type: "BOOLEAN"})
+	for _, name := range []string{"Ord", "Succ", "Pred", "Low", "High"} {
+		symtab.define(BuiltinRoutineSymbol{Name: name})
+	}
 }
 
 func (symtab *SymbolTable) visitProgram(t ast.Program) {
+	symtab.mode = t.Mode
+	symtab.enterScope(t.Name)
 	symtab.visitBlock(t.Block)
+	symtab.leaveScope()
 }
 
 func (symtab *SymbolTable) visitBlock(t ast.Block) {
+	for _, def := range t.Decl.Definitions() {
+		switch d := def.(type) {
+		case ast.ConstDecl:
+			symtab.visitConstDecl(d)
+		case ast.TypeDecl:
+			symtab.visitTypeDecl(d)
+		}
+	}
 	for _, vardecl := range t.Decl.VarDeclList {
 		symtab.visitVarDecl(vardecl)
 	}
-
-	// for _, procedureDecl := range t.Decl.ProceDeclList {
-	// 	//todo
-	// }
+	for _, routine := range t.Decl.Routines() {
+		switch r := routine.(type) {
+		case ast.Procedure:
+			symtab.visitProcedure(r)
+		case ast.Function:
+			symtab.visitFunction(r)
+		}
+	}
 	symtab.visitCompound(t.Compound)
+	symtab.checkFlow(t)
+}
+
+//visitConstDecl computes the value of a constant, a typed constant holds it converted to its type
+func (symtab *SymbolTable) visitConstDecl(t ast.ConstDecl) {
+	name := t.Node.Literal
+	if symtab.lookupLocal(name) != nil {
+		msg := fmt.Sprintf("Duplicate  identifier %s", name)
+		symtab.addError(diagnostics.DuplicateIdentifier, t.Node.Span, msg)
+		return
+	}
+	valueType := symtab.visitExpr(t.Value)
+	var rng *Subrange
+	constSymbol := ConstSymbol{Name: name}
+	if t.Type != "" {
+		constSymbol.Type, rng = symtab.resolveType(string(t.Type), t.Span)
+	}
+	value, ok := symtab.evalConst(t.Value)
+	switch {
+	case !ok:
+		// an expression with errors is reported already
+		if valueType != "" {
+			msg := fmt.Sprintf("value of constant %s must be a constant expression", name)
+			symtab.addError(diagnostics.InvalidConstant, t.Value.GetSpan(), msg)
+		}
+	case value.Type == "":
+	case t.Type == "":
+		constSymbol.Type = value.Type
+		constSymbol.Value = value
+	case constSymbol.Type == "":
+	case !assignable(constSymbol.Type, value.Type):
+		msg := fmt.Sprintf("cannot assign %s to %s constant %s", value.Type, t.Type, name)
+		symtab.addError(diagnostics.TypeMismatch, t.Value.GetSpan(), msg)
+	default:
+		constSymbol.Value = value.convert(constSymbol.Type)
+		symtab.checkRange(t.Value, rng, "constant "+name)
+	}
+	symtab.define(constSymbol)
+}
+
+//visitTypeDecl defines a type name, an alias stands for the type it names and a subrange
+//for the ordinal type of its bounds, which are constants and not empty. The values of
+//an enumeration are constants of the scope the type is declared in
+func (symtab *SymbolTable) visitTypeDecl(t ast.TypeDecl) {
+	name := t.Node.Literal
+	if symtab.lookupLocal(name) != nil {
+		msg := fmt.Sprintf("Duplicate  identifier %s", name)
+		symtab.addError(diagnostics.DuplicateIdentifier, t.Node.Span, msg)
+		return
+	}
+	typeSymbol := TypeSymbol{Name: name}
+	if t.Values != nil {
+		typeSymbol.Base = name
+		symtab.define(typeSymbol)
+		values := make([]string, 0, len(t.Values))
+		for i, value := range t.Values {
+			values = append(values, value.Literal)
+			if symtab.lookupLocal(value.Literal) != nil {
+				msg := fmt.Sprintf("Duplicate  identifier %s", value.Literal)
+				symtab.addError(diagnostics.DuplicateIdentifier, value.Span, msg)
+				continue
+			}
+			symtab.define(ConstSymbol{Name: value.Literal, Type: name, Value: Constant{Type: name, Value: int64(i)}})
+		}
+		symtab.enums[name] = values
+		return
+	}
+	if t.Low == nil {
+		typeSymbol.Base, typeSymbol.Range = symtab.resolveType(string(t.Type), t.Span)
+		symtab.define(typeSymbol)
+		return
+	}
+
+	lowType := symtab.visitExpr(t.Low)
+	highType := symtab.visitExpr(t.High)
+	low, lowOrdinal, lok := symtab.constOrdinal(t.Low)
+	high, highOrdinal, hok := symtab.constOrdinal(t.High)
+	switch {
+	case lowType == "" || highType == "":
+		// an expression with errors is reported already
+	case !lok || !hok:
+		msg := fmt.Sprintf("bounds of subrange %s must be ordinal constants", name)
+		symtab.addError(diagnostics.InvalidConstant, t.Span, msg)
+	case lowOrdinal != highOrdinal:
+		msg := fmt.Sprintf("bounds of subrange %s must be of the same type, got %s and %s", name, lowOrdinal, highOrdinal)
+		symtab.addError(diagnostics.TypeMismatch, t.Span, msg)
+	case low > high:
+		msg := fmt.Sprintf("subrange %s is empty", name)
+		symtab.addError(diagnostics.InvalidConstant, t.Span, msg)
+	default:
+		typeSymbol.Base = lowOrdinal
+		typeSymbol.Range = &Subrange{Low: low, High: high}
+	}
+	symtab.define(typeSymbol)
+}
+
+//resolveType returns the builtin type a type name stands for and the bounds of a subrange,
+//a name which is no type is reported at span and gives an empty type
+func (symtab *SymbolTable) resolveType(name string, span ast.Span) (string, *Subrange) {
+	switch name {
+	case token.INTEGER, token.REAL, token.CHAR, token.STRING, token.BOOLEAN:
+		return name, nil
+	}
+	switch symbol := symtab.lookup(name).(type) {
+	case TypeSymbol:
+		return symbol.Base, symbol.Range
+	case nil:
+		msg := fmt.Sprintf("type %s undeclared", name)
+		symtab.addError(diagnostics.Undeclared, span, msg)
+	default:
+		msg := fmt.Sprintf("%s is not a type", name)
+		symtab.addError(diagnostics.WrongKind, span, msg)
+	}
+	return "", nil
+}
+
+//checkRange reports a constant value outside the subrange of a target, what names the target
+func (symtab *SymbolTable) checkRange(value ast.Expr, rng *Subrange, what string) {
+	if rng == nil {
+		return
+	}
+	v, typeName, ok := symtab.constOrdinal(value)
+	if !ok || v >= rng.Low && v <= rng.High {
+		return
+	}
+	msg := fmt.Sprintf("constant %s is out of range %s..%s of %s",
+		symtab.formatOrdinal(v, typeName), symtab.formatOrdinal(rng.Low, typeName), symtab.formatOrdinal(rng.High, typeName), what)
+	symtab.addError(diagnostics.OutOfRange, value.GetSpan(), msg)
+}
+
+//formatOrdinal writes an ordinal number as a pascal literal of the type,
+//a value of an enumerated type by its name
+func (symtab *SymbolTable) formatOrdinal(value int64, typeName string) string {
+	if values, ok := symtab.enums[typeName]; ok && value >= 0 && value < int64(len(values)) {
+		return values[value]
+	}
+	switch typeName {
+	case token.CHAR:
+		return fmt.Sprintf("'%c'", rune(value))
+	case token.BOOLEAN:
+		if value != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return strconv.FormatInt(value, 10)
 }
 
 func (symtab *SymbolTable) visitVarDecl(t ast.VarDecl) {
-	typeName := string(t.Type)
 	varName := t.Node.Literal
-	varSymbol := VarSymbol{Type: typeName, Name: varName}
-	symbol := symtab.lookup(varName)
+	typeName, rng := symtab.resolveType(string(t.Type), t.Span)
+	varSymbol := VarSymbol{Type: typeName, Name: varName, Range: rng}
+	symbol := symtab.lookupLocal(varName)
 	if symbol != nil {
 		msg := fmt.Sprintf("Duplicate  identifier %s", varName)
-		err := errors.New(msg)
-		symtab.addError(err)
+		symtab.addError(diagnostics.DuplicateIdentifier, t.Node.Span, msg)
 		return
 	}
 	symtab.define(varSymbol)
@@ -110,29 +460,387 @@ func (symtab *SymbolTable) visitStatement(t ast.Statement) {
 		symtab.visitAssignment(node)
 	case ast.Compound:
 		symtab.visitCompound(node)
+	case ast.IfStatement:
+		symtab.visitIf(node)
+	case ast.WhileStatement:
+		symtab.visitWhile(node)
+	case ast.RepeatStatement:
+		symtab.visitRepeat(node)
+	case ast.ForStatement:
+		symtab.visitFor(node)
+	case ast.CaseStatement:
+		symtab.visitCase(node)
+	case ast.ProcedureCall:
+		symtab.visitProcedureCall(node)
 	case ast.NoOp:
 		return
 	}
 }
 
+func (symtab *SymbolTable) visitIf(t ast.IfStatement) {
+	symtab.Visit(t.Cond)
+	symtab.checkCondition("IF", t.Cond)
+	symtab.Visit(t.Then)
+	if t.Else != nil {
+		symtab.Visit(t.Else)
+	}
+}
+
+func (symtab *SymbolTable) visitWhile(t ast.WhileStatement) {
+	symtab.Visit(t.Cond)
+	symtab.checkCondition("WHILE", t.Cond)
+	symtab.Visit(t.Body)
+}
+
+func (symtab *SymbolTable) visitRepeat(t ast.RepeatStatement) {
+	for _, st := range t.Body {
+		symtab.Visit(st)
+	}
+	symtab.Visit(t.Cond)
+	symtab.checkCondition("REPEAT", t.Cond)
+}
+
+//visitFor checks the control variable is an ordinal variable, of the type of
+//the bounds, and that it is not assigned in the body
+func (symtab *SymbolTable) visitFor(t ast.ForStatement) {
+	symtab.Visit(t.Var)
+	symtab.Visit(t.Start)
+	symtab.Visit(t.Stop)
+
+	name := token.Canonical(t.Var.Literal)
+	varType := symtab.TypeOf(t.Var)
+	symbol := symtab.lookup(t.Var.Literal)
+	if param, ok := symbol.(ParamSymbol); symbol != nil && (symtab.lookupLocal(t.Var.Literal) == nil || ok && param.Mode != "" || isConst(symbol)) {
+		msg := fmt.Sprintf("FOR control variable %s must be a local variable", t.Var.Literal)
+		symtab.addError(diagnostics.InvalidForVariable, t.Var.Span, msg)
+	}
+	if varType != "" && !symtab.isOrdinal(varType) {
+		msg := fmt.Sprintf("FOR control variable %s must be of an ordinal type, got %s", t.Var.Literal, varType)
+		symtab.addError(diagnostics.InvalidForVariable, t.Var.Span, msg)
+		varType = ""
+	}
+	varSymbol, _ := symbol.(VarSymbol)
+	for _, bound := range []ast.Expr{t.Start, t.Stop} {
+		boundType := symtab.TypeOf(bound)
+		if varType != "" && boundType != "" && boundType != varType {
+			msg := fmt.Sprintf("FOR bound of type %s does not match control variable %s of type %s", boundType, t.Var.Literal, varType)
+			symtab.addError(diagnostics.TypeMismatch, bound.GetSpan(), msg)
+			continue
+		}
+		symtab.checkRange(bound, varSymbol.Range, "control variable "+t.Var.Literal)
+	}
+	enclosed := symtab.forVars[name]
+	if enclosed {
+		msg := fmt.Sprintf("%s is already the control variable of an enclosing FOR", t.Var.Literal)
+		symtab.addError(diagnostics.InvalidForVariable, t.Var.Span, msg)
+	}
+
+	if symtab.forVars == nil {
+		symtab.forVars = make(map[string]bool)
+	}
+	symtab.forVars[name] = true
+	symtab.Visit(t.Body)
+	if !enclosed {
+		delete(symtab.forVars, name)
+	}
+}
+
+type caseRange struct {
+	low   int64
+	high  int64
+	start token.Position
+}
+
+//visitCase checks the selector is ordinal and the labels are constants of its type,
+//which neither repeat nor overlap
+func (symtab *SymbolTable) visitCase(t ast.CaseStatement) {
+	symtab.Visit(t.Selector)
+	selType := symtab.TypeOf(t.Selector)
+	if selType != "" && !symtab.isOrdinal(selType) {
+		msg := fmt.Sprintf("CASE selector must be of an ordinal type, got %s", selType)
+		symtab.addError(diagnostics.TypeMismatch, t.Selector.GetSpan(), msg)
+		selType = ""
+	}
+
+	seen := make([]caseRange, 0)
+	for _, branch := range t.Branches {
+		for _, label := range branch.Labels {
+			low, lowType, ok := symtab.constOrdinal(label.Low)
+			high, highType := low, lowType
+			if ok && label.High != nil {
+				high, highType, ok = symtab.constOrdinal(label.High)
+			}
+			if !ok {
+				msg := "case label must be an ordinal constant"
+				symtab.addError(diagnostics.InvalidCaseLabel, label.Span, msg)
+				continue
+			}
+			if lowType != highType {
+				msg := "case label range bounds must be of the same type"
+				symtab.addError(diagnostics.InvalidCaseLabel, label.Span, msg)
+				continue
+			}
+			if selType != "" && lowType != selType {
+				msg := fmt.Sprintf("case label of type %s does not match selector of type %s", lowType, selType)
+				symtab.addError(diagnostics.TypeMismatch, label.Span, msg)
+				continue
+			}
+			if low > high {
+				msg := "case label range is empty"
+				symtab.addError(diagnostics.InvalidCaseLabel, label.Span, msg)
+				continue
+			}
+			for _, other := range seen {
+				if low <= other.high && other.low <= high {
+					msg := fmt.Sprintf("duplicate case label, overlaps the label at %s", other.start)
+					symtab.addError(diagnostics.InvalidCaseLabel, label.Span, msg)
+					break
+				}
+			}
+			seen = append(seen, caseRange{low: low, high: high, start: label.Start})
+		}
+		symtab.Visit(branch.Body)
+	}
+	for _, st := range t.Else {
+		symtab.Visit(st)
+	}
+}
+
+//constOrdinal returns the ordinal value and the type name of a constant expression,
+//ok is false when it is not an ordinal constant
+func (symtab *SymbolTable) constOrdinal(node ast.Expr) (value int64, typeName string, ok bool) {
+	c, ok := symtab.evalConst(node)
+	if !ok || !symtab.isOrdinal(c.Type) {
+		return 0, "", false
+	}
+	return c.Ordinal(), c.Type, true
+}
+
+//isOrdinal reports whether the type is INTEGER, CHAR, BOOLEAN or an enumerated type
+func (symtab *SymbolTable) isOrdinal(typeName string) bool {
+	_, enum := symtab.enums[typeName]
+	return enum || typeName == token.INTEGER || typeName == token.CHAR || typeName == token.BOOLEAN
+}
+
+//checkCondition reports a condition of the statement which is not BOOLEAN
+func (symtab *SymbolTable) checkCondition(statement string, cond ast.Expr) {
+	condType := symtab.TypeOf(cond)
+	if condType != "" && condType != token.BOOLEAN {
+		msg := fmt.Sprintf("condition of %s must be BOOLEAN, got %s", statement, condType)
+		symtab.addError(diagnostics.TypeMismatch, cond.GetSpan(), msg)
+	}
+}
+
+//TypeOf returns the type name computed for an expression, or empty when it is unknown
+func (symtab *SymbolTable) TypeOf(node ast.Expr) string {
+	if node == nil {
+		return ""
+	}
+	return symtab.Types[node.GetSpan()]
+}
+
+//visitExpr checks an expression and records its type, empty when it is unknown
+//because of an error already reported
+func (symtab *SymbolTable) visitExpr(node ast.Expr) string {
+	var exprType string
+	switch t := node.(type) {
+	case ast.NumNode:
+		exprType = string(t.Tok.Type)
+	case ast.StringNode:
+		exprType = token.STRING
+		if t.Tok.Type == token.CHAR_CONST {
+			exprType = token.CHAR
+		}
+	case ast.BoolNode:
+		exprType = token.BOOLEAN
+	case ast.VarNode:
+		exprType = symtab.visitVar(t)
+	case ast.FunctionCall:
+		exprType = symtab.visitFunctionCall(t)
+	case ast.Unary:
+		exprType = symtab.visitUnary(t)
+	case ast.BinNode:
+		exprType = symtab.visitBinNode(t)
+	}
+	symtab.Types[node.GetSpan()] = exprType
+	return exprType
+}
+
+func (symtab *SymbolTable) visitUnary(t ast.Unary) string {
+	operand := symtab.visitExpr(t.Expr)
+	if operand == "" {
+		return ""
+	}
+	if t.Op == token.NOT && (operand == token.BOOLEAN || operand == token.INTEGER) || t.Op != token.NOT && isNumeric(operand) {
+		return operand
+	}
+	op := map[string]string{token.MINUS: "-", token.PLUS: "+", token.NOT: "not"}[t.Op]
+	msg := fmt.Sprintf("operator %s not applicable to %s", op, operand)
+	symtab.addError(diagnostics.TypeMismatch, t.Span, msg)
+	return ""
+}
+
+//visitBinNode checks the operands of a binary operator match, the result of / is always
+//REAL and DIV and MOD take INTEGER operands only
+func (symtab *SymbolTable) visitBinNode(t ast.BinNode) string {
+	left := symtab.visitExpr(t.Left)
+	right := symtab.visitExpr(t.Right)
+	if left == "" || right == "" {
+		return ""
+	}
+
+	result := ""
+	switch t.Tok.Type {
+	case token.EQ, token.NE, token.LT, token.LE, token.GT, token.GE:
+		if comparable(left, right) {
+			return token.BOOLEAN
+		}
+	case token.AND, token.OR, token.XOR:
+		if left == right && (left == token.BOOLEAN || left == token.INTEGER) {
+			result = left
+		}
+	case token.PLUS:
+		if isString(left) && isString(right) {
+			result = token.STRING
+		} else {
+			result = arithmetic(left, right)
+		}
+	case token.MINUS, token.MUL:
+		result = arithmetic(left, right)
+	case token.REAL_DIV:
+		if isNumeric(left) && isNumeric(right) {
+			result = token.REAL
+		}
+	case token.INTEGER_DIV, token.MOD:
+		if left == token.INTEGER && right == token.INTEGER {
+			result = token.INTEGER
+		}
+	}
+	if result == "" {
+		msg := fmt.Sprintf("operator %s not applicable to %s and %s", t.Tok.Literal, left, right)
+		symtab.addError(diagnostics.TypeMismatch, ast.Span{Start: t.Tok.Pos, End: t.Tok.End}, msg)
+	}
+	return result
+}
+
+func isNumeric(typeName string) bool {
+	return typeName == token.INTEGER || typeName == token.REAL
+}
+
+func isString(typeName string) bool {
+	return typeName == token.STRING || typeName == token.CHAR
+}
+
+//arithmetic returns the type of + - * on numbers, INTEGER only when both are INTEGER
+func arithmetic(left string, right string) string {
+	if !isNumeric(left) || !isNumeric(right) {
+		return ""
+	}
+	if left == token.INTEGER && right == token.INTEGER {
+		return token.INTEGER
+	}
+	return token.REAL
+}
+
+//comparable reports whether values of the two types can be compared
+func comparable(left string, right string) bool {
+	return left == right || isNumeric(left) && isNumeric(right) || isString(left) && isString(right)
+}
+
 func (symtab *SymbolTable) visitAssignment(st ast.AssignStatement) {
 	varName := st.Left.Literal
 	res := symtab.lookup(varName)
 	if res == nil {
 		msg := fmt.Sprintf("varname %s undeclared", varName)
-		err := errors.New(msg)
-		symtab.addError(err)
+		symtab.addError(diagnostics.Undeclared, st.Left.Span, msg)
 		return
 	}
+	switch symbol := res.(type) {
+	case FunctionSymbol:
+		if !symtab.inBody(symbol.Name) {
+			msg := fmt.Sprintf("cannot assign to function %s outside of its body", varName)
+			symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+		}
+	case ParamSymbol:
+		if symbol.Mode == token.CONST {
+			msg := fmt.Sprintf("cannot assign to CONST parameter %s", varName)
+			symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+		}
+	case ProcedureSymbol:
+		msg := fmt.Sprintf("cannot assign to procedure %s", varName)
+		symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+	case ConstSymbol:
+		msg := fmt.Sprintf("cannot assign to constant %s", varName)
+		symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+	case TypeSymbol:
+		msg := fmt.Sprintf("cannot assign to type %s", varName)
+		symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+	case BuiltinRoutineSymbol:
+		msg := fmt.Sprintf("cannot assign to standard function %s", varName)
+		symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+	}
+	if symtab.forVars[token.Canonical(varName)] {
+		msg := fmt.Sprintf("cannot assign to FOR control variable %s", varName)
+		symtab.addError(diagnostics.InvalidAssignment, st.Left.Span, msg)
+	}
 	symtab.Visit(st.Right)
+	symtab.checkAssignment(st, res)
+}
+
+//checkAssignment reports a value whose type cannot be stored in the target,
+//a REAL in an INTEGER in particular
+func (symtab *SymbolTable) checkAssignment(st ast.AssignStatement, target Symbol) {
+	var targetType string
+	var rng *Subrange
+	switch symbol := target.(type) {
+	case VarSymbol:
+		targetType, rng = symbol.Type, symbol.Range
+	case ParamSymbol:
+		targetType, rng = symbol.Type, symbol.Range
+	case FunctionSymbol:
+		targetType = symbol.ReturnType
+	default:
+		return
+	}
+	valueType := symtab.TypeOf(st.Right)
+	if valueType == "" || targetType == "" {
+		return
+	}
+	if assignable(targetType, valueType) {
+		symtab.checkRange(st.Right, rng, "variable "+st.Left.Literal)
+		return
+	}
+	if bin, ok := st.Right.(ast.BinNode); ok && bin.Tok.Type == token.REAL_DIV && targetType == token.INTEGER {
+		msg := fmt.Sprintf("result of / is always REAL, cannot assign it to INTEGER variable %s", st.Left.Literal)
+		symtab.addError(diagnostics.TypeMismatch, st.Right.GetSpan(), msg)
+		return
+	}
+	msg := fmt.Sprintf("cannot assign %s to %s variable %s", valueType, targetType, st.Left.Literal)
+	symtab.addError(diagnostics.TypeMismatch, st.Right.GetSpan(), msg)
 }
 
-func (symtab *SymbolTable) addError(err error) {
+//inBody reports whether the analyzer is in the body of the routine name or in a routine nested in it
+func (symtab *SymbolTable) inBody(name string) bool {
+	for scope := symtab.CurrentScope; scope != nil && scope.ScopeLevel > 1; scope = scope.EnclosingScope {
+		if token.Canonical(scope.ScopeName) == token.Canonical(name) {
+			return true
+		}
+	}
+	return false
+}
+
+//addError records a *diagnostics.SemanticError
+func (symtab *SymbolTable) addError(code diagnostics.Code, span ast.Span, msg string) {
 	errList := symtab.ErrorList
-	errList = append(errList, err)
+	errList = append(errList, diagnostics.NewSemanticError(code, span, msg))
 	symtab.ErrorList = errList
 }
 
+//addWarning records a *diagnostics.SemanticError of severity Warning
+func (symtab *SymbolTable) addWarning(code diagnostics.Code, span ast.Span, msg string) {
+	symtab.ErrorList = append(symtab.ErrorList, diagnostics.NewSemanticWarning(code, span, msg))
+}
+
 func (symtab *SymbolTable) Visit(astTree ast.Expr) {
 	if astTree == nil {
 		return
@@ -151,15 +859,22 @@ func (symtab *SymbolTable) Visit(astTree ast.Expr) {
 		symtab.visitAssignment(t)
 	case ast.Statement:
 		symtab.visitStatement(t)
-	case ast.BinNode:
-		symtab.visitBinNode(t)
-	case ast.Unary:
-		symtab.Visit(t.Expr)
-
-	case ast.NumNode:
-
-	case ast.VarNode:
-		symtab.visitVar(t)
+	case ast.IfStatement:
+		symtab.visitIf(t)
+	case ast.WhileStatement:
+		symtab.visitWhile(t)
+	case ast.RepeatStatement:
+		symtab.visitRepeat(t)
+	case ast.ForStatement:
+		symtab.visitFor(t)
+	case ast.CaseStatement:
+		symtab.visitCase(t)
+	case ast.ProcedureCall:
+		symtab.visitProcedureCall(t)
+	case ast.Function:
+		symtab.visitFunction(t)
+	case ast.FunctionCall, ast.BinNode, ast.Unary, ast.NumNode, ast.StringNode, ast.BoolNode, ast.VarNode:
+		symtab.visitExpr(t)
 	case ast.Procedure:
 		symtab.visitProcedure(t)
 
@@ -168,24 +883,205 @@ func (symtab *SymbolTable) Visit(astTree ast.Expr) {
 	}
 }
 
-func (symtab *SymbolTable) visitBinNode(t ast.BinNode) {
-	symtab.Visit(t.Left)
-	symtab.Visit(t.Right)
-}
-
-func (symtab *SymbolTable) visitVar(t ast.VarNode) {
+func (symtab *SymbolTable) visitVar(t ast.VarNode) string {
 	name := t.Literal
 	symbol := symtab.lookup(name)
 	if symbol == nil {
 		msg := fmt.Sprintf("varname %s undeclared", name)
-		err := errors.New(msg)
-		symtab.addError(err)
-		return
+		symtab.addError(diagnostics.Undeclared, t.Span, msg)
+		return ""
 	}
+	if _, ok := symbol.(ProcedureSymbol); ok {
+		msg := fmt.Sprintf("procedure %s used as a value", name)
+		symtab.addError(diagnostics.WrongKind, t.Span, msg)
+		return ""
+	}
+	switch symbol.(type) {
+	case TypeSymbol, BuiltinTypeSymbol:
+		msg := fmt.Sprintf("type %s used as a value", name)
+		symtab.addError(diagnostics.WrongKind, t.Span, msg)
+		return ""
+	}
+	if _, ok := symbol.(BuiltinRoutineSymbol); ok {
+		return symtab.visitBuiltinCall(name, t.Span, nil)
+	}
+	// a function read as a value is called without arguments
+	if fs, ok := symbol.(FunctionSymbol); ok {
+		symtab.checkArguments(name, t.Span, fs.Params, nil)
+	}
+	return symbol.ShowType()
 }
 
+//visitProcedure defines the procedure in the current scope and checks its block
+//in a new scope holding the parameters and the locals
 func (symtab *SymbolTable) visitProcedure(t ast.Procedure) {
-	return
+	procSymbol := ProcedureSymbol{Name: t.Name, Params: symtab.paramSymbols(t.Params)}
+	if symtab.lookupLocal(t.Name) != nil {
+		msg := fmt.Sprintf("Duplicate  identifier %s", t.Name)
+		symtab.addError(diagnostics.DuplicateIdentifier, t.Span, msg)
+	} else {
+		symtab.define(procSymbol)
+	}
+	symtab.visitRoutine(t.Name, t.Params, procSymbol.Params, nil, t.Block)
+}
+
+//visitFunction is visitProcedure for a function, in the delphi and objfpc modes
+//its scope also holds the Result variable
+func (symtab *SymbolTable) visitFunction(t ast.Function) {
+	funcSymbol := FunctionSymbol{Name: t.Name, Params: symtab.paramSymbols(t.Params)}
+	var rng *Subrange
+	funcSymbol.ReturnType, rng = symtab.resolveType(string(t.ReturnType), t.Span)
+	if symtab.lookupLocal(t.Name) != nil {
+		msg := fmt.Sprintf("Duplicate  identifier %s", t.Name)
+		symtab.addError(diagnostics.DuplicateIdentifier, t.Span, msg)
+	} else {
+		symtab.define(funcSymbol)
+	}
+	var result Symbol
+	if symtab.mode == "DELPHI" || symtab.mode == "OBJFPC" {
+		result = VarSymbol{Name: "Result", Type: funcSymbol.ReturnType, Range: rng}
+	}
+	symtab.visitRoutine(t.Name, t.Params, funcSymbol.Params, result, t.Block)
+}
+
+//visitRoutine checks the block of a procedure or function in its own scope, symbols are
+//the parameters with their types resolved and result is the Result variable of a function or nil
+func (symtab *SymbolTable) visitRoutine(name string, params []ast.Param, symbols []ParamSymbol, result Symbol, block ast.Block) {
+	forVars := symtab.forVars
+	symtab.forVars = nil
+	symtab.enterScope(name)
+	for i, param := range params {
+		if symtab.lookupLocal(param.Node.Literal) != nil {
+			msg := fmt.Sprintf("Duplicate  identifier %s", param.Node.Literal)
+			symtab.addError(diagnostics.DuplicateIdentifier, param.Node.Span, msg)
+			continue
+		}
+		symtab.define(symbols[i])
+	}
+	if result != nil {
+		symtab.define(result)
+	}
+	symtab.visitBlock(block)
+	symtab.leaveScope()
+	symtab.forVars = forVars
+}
+
+//paramSymbols returns the symbols of the formal parameters, their types are resolved
+//in the scope the routine is declared in
+func (symtab *SymbolTable) paramSymbols(params []ast.Param) []ParamSymbol {
+	symbols := make([]ParamSymbol, 0)
+	for _, param := range params {
+		typeName, rng := symtab.resolveType(string(param.Type), param.Span)
+		symbols = append(symbols, ParamSymbol{Name: param.Node.Literal, Type: typeName, Mode: string(param.Mode), Range: rng})
+	}
+	return symbols
+}
+
+func (symtab *SymbolTable) visitProcedureCall(t ast.ProcedureCall) {
+	symbol := symtab.lookup(t.Name)
+	if _, ok := symbol.(BuiltinRoutineSymbol); ok {
+		symtab.visitBuiltinCall(t.Name, t.Span, t.Args)
+		return
+	}
+	for _, arg := range t.Args {
+		symtab.Visit(arg)
+	}
+	if symbol == nil {
+		msg := fmt.Sprintf("procedure %s undeclared", t.Name)
+		symtab.addError(diagnostics.Undeclared, t.Span, msg)
+		return
+	}
+	// a function may be called as a statement, dropping its result
+	switch routine := symbol.(type) {
+	case ProcedureSymbol:
+		symtab.checkArguments(t.Name, t.Span, routine.Params, t.Args)
+	case FunctionSymbol:
+		symtab.checkArguments(t.Name, t.Span, routine.Params, t.Args)
+	default:
+		msg := fmt.Sprintf("%s is not a procedure", t.Name)
+		symtab.addError(diagnostics.WrongKind, t.Span, msg)
+	}
+}
+
+func (symtab *SymbolTable) visitFunctionCall(t ast.FunctionCall) string {
+	symbol := symtab.lookup(t.Name)
+	if _, ok := symbol.(BuiltinRoutineSymbol); ok {
+		return symtab.visitBuiltinCall(t.Name, t.Span, t.Args)
+	}
+	for _, arg := range t.Args {
+		symtab.Visit(arg)
+	}
+	if symbol == nil {
+		msg := fmt.Sprintf("function %s undeclared", t.Name)
+		symtab.addError(diagnostics.Undeclared, t.Span, msg)
+		return ""
+	}
+	funcSymbol, ok := symbol.(FunctionSymbol)
+	if !ok {
+		msg := fmt.Sprintf("%s is not a function", t.Name)
+		symtab.addError(diagnostics.WrongKind, t.Span, msg)
+		return ""
+	}
+	symtab.checkArguments(t.Name, t.Span, funcSymbol.Params, t.Args)
+	return funcSymbol.ReturnType
+}
+
+//checkArguments checks the arguments match the formal parameters of the routine name,
+//an argument for a VAR parameter must be a variable of the very same type and no CONST parameter
+func (symtab *SymbolTable) checkArguments(name string, span ast.Span, params []ParamSymbol, args []ast.Expr) {
+	if len(args) != len(params) {
+		msg := fmt.Sprintf("wrong number of arguments to %s, got %d and expected %d", name, len(args), len(params))
+		symtab.addError(diagnostics.ArgumentCount, span, msg)
+		return
+	}
+
+	for i, param := range params {
+		arg := args[i]
+		argType := symtab.TypeOf(arg)
+		if param.Mode == token.VAR {
+			if _, ok := arg.(ast.VarNode); !ok || isConst(symtab.lookup(arg.ToStr())) {
+				msg := fmt.Sprintf("argument for VAR parameter %s of %s must be a variable", param.Name, name)
+				symtab.addError(diagnostics.InvalidAssignment, arg.GetSpan(), msg)
+				continue
+			}
+			if symbol, ok := symtab.lookup(arg.ToStr()).(ParamSymbol); ok && symbol.Mode == token.CONST {
+				msg := fmt.Sprintf("cannot pass CONST parameter %s as VAR parameter", arg.ToStr())
+				symtab.addError(diagnostics.InvalidAssignment, arg.GetSpan(), msg)
+				continue
+			}
+			if symtab.forVars[token.Canonical(arg.ToStr())] {
+				msg := fmt.Sprintf("cannot pass FOR control variable %s as VAR parameter", arg.ToStr())
+				symtab.addError(diagnostics.InvalidForVariable, arg.GetSpan(), msg)
+			}
+			if argType != "" && param.Type != "" && argType != param.Type {
+				msg := fmt.Sprintf("argument of type %s for VAR parameter %s of %s must be of type %s", argType, param.Name, name, param.Type)
+				symtab.addError(diagnostics.TypeMismatch, arg.GetSpan(), msg)
+			}
+			continue
+		}
+		if argType == "" || param.Type == "" {
+			continue
+		}
+		if !assignable(param.Type, argType) {
+			msg := fmt.Sprintf("argument of type %s is not compatible with parameter %s of %s of type %s", argType, param.Name, name, param.Type)
+			symtab.addError(diagnostics.TypeMismatch, arg.GetSpan(), msg)
+			continue
+		}
+		symtab.checkRange(arg, param.Range, fmt.Sprintf("parameter %s of %s", param.Name, name))
+	}
+}
+
+func isConst(symbol Symbol) bool {
+	_, ok := symbol.(ConstSymbol)
+	return ok
+}
+
+//assignable reports whether a value of type valueType can be assigned to a target of type target
+func assignable(target string, valueType string) bool {
+	if target == valueType {
+		return true
+	}
+	return (target == token.REAL && valueType == token.INTEGER) || (target == token.STRING && valueType == token.CHAR)
 }
 
 func (symtab *SymbolTable) visitDecl(t ast.Decl) {
@@ -0,0 +1,654 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"pascal_in_go/ast"
+	"pascal_in_go/diagnostics"
+	"pascal_in_go/lexer"
+	"pascal_in_go/parser"
+	"strings"
+	"testing"
+)
+
+func check(text string) []error {
+	return checkSeverity(text, diagnostics.Error)
+}
+
+//checkSeverity returns the diagnostics of the severity found in text, a syntax error is an error
+func checkSeverity(text string, severity diagnostics.Severity) []error {
+	lexer := lexer.NewLexer(text)
+	parser := parser.NewParser(lexer)
+	symtab := NewSymbolTable()
+	symtab.InitBuiltins()
+	tree, err := parser.Program()
+	if err != nil {
+		return []error{err}
+	}
+	symtab.Visit(tree)
+	errList := make([]error, 0)
+	for _, err := range symtab.ErrorList {
+		if err.(diagnostics.Diagnostic).GetSeverity() == severity {
+			errList = append(errList, err)
+		}
+	}
+	return errList
+}
+
+func expectErrors(t *testing.T, text string, expect []string) {
+	expectDiagnostics(t, text, check(text), expect)
+}
+
+func expectWarnings(t *testing.T, text string, expect []string) {
+	expectDiagnostics(t, text, checkSeverity(text, diagnostics.Warning), expect)
+}
+
+func expectDiagnostics(t *testing.T, text string, errList []error, expect []string) {
+	if len(errList) != len(expect) {
+		t.Fatalf("errors are %+v; expected  %+v, text is %s\n ", errList, expect, text)
+	}
+	for i, want := range expect {
+		if !strings.Contains(errList[i].Error(), want) {
+			t.Errorf("error is %+v; expected  %+v, text is %s\n ", errList[i], want, text)
+		}
+	}
+}
+
+func TestIfCondition(t *testing.T) {
+	text := `program Cond;
+var x : integer;
+begin
+  if x then x := 1;
+  if x > 0 then x := 2
+end.`
+	expectErrors(t, text, []string{"4:6: condition of IF must be BOOLEAN, got INTEGER"})
+}
+
+func TestLoopCondition(t *testing.T) {
+	text := `program Cond;
+var x : integer;
+begin
+  while x do x := 1;
+  repeat x := 2 until 'a'
+end.`
+	expectErrors(t, text, []string{
+		"4:9: condition of WHILE must be BOOLEAN, got INTEGER",
+		"5:23: condition of REPEAT must be BOOLEAN, got CHAR",
+	})
+}
+
+func TestForControlVariable(t *testing.T) {
+	text := `program Loops;
+var i, j : integer;
+    r : real;
+begin
+  for r := 1 to 2 do ;
+  for i := 1 to 'z' do ;
+  for i := 1 to 10 do
+  begin
+    i := 5;
+    for i := 1 to 2 do j := i
+  end;
+  i := 1
+end.`
+	expectErrors(t, text, []string{
+		"5:7: FOR control variable r must be of an ordinal type, got REAL",
+		"6:17: FOR bound of type CHAR does not match control variable i of type INTEGER",
+		"9:5: cannot assign to FOR control variable i",
+		"10:9: i is already the control variable of an enclosing FOR",
+	})
+}
+
+func TestCaseLabels(t *testing.T) {
+	text := `program Cases;
+var i : integer;
+    r : real;
+begin
+  case i of
+    1, 2: ;
+    2: ;
+    3..5: ;
+    4..8: ;
+    i: ;
+    'a': ;
+    9..7: ;
+    -2: ;
+  else
+    i := 0
+  end;
+  case r of
+    1: ;
+  end
+end.`
+	expectErrors(t, text, []string{
+		"7:5: duplicate case label, overlaps the label at 6:8",
+		"9:5: duplicate case label, overlaps the label at 8:5",
+		"10:5: case label must be an ordinal constant",
+		"11:5: case label of type CHAR does not match selector of type INTEGER",
+		"12:5: case label range is empty",
+		"17:8: CASE selector must be of an ordinal type, got REAL",
+	})
+}
+
+func TestProcedureCallArguments(t *testing.T) {
+	text := `program Calls;
+var a : integer;
+    r : real;
+
+procedure P(x : real; var y : integer);
+begin
+end;
+
+procedure S(const c : integer);
+begin
+  P(1, c)
+end;
+
+begin
+  P(1, a);
+  P(a, a, a);
+  P(1, 2);
+  P(1, r);
+  P('s', a);
+  Q;
+  a(1)
+end.`
+	expectErrors(t, text, []string{
+		"11:8: cannot pass CONST parameter c as VAR parameter",
+		"16:3: wrong number of arguments to P, got 3 and expected 2",
+		"17:8: argument for VAR parameter y of P must be a variable",
+		"18:8: argument of type REAL for VAR parameter y of P must be of type INTEGER",
+		"19:5: argument of type CHAR is not compatible with parameter x of P of type REAL",
+		"20:3: procedure Q undeclared",
+		"21:3: a is not a procedure",
+	})
+}
+
+func TestFunctionCallArguments(t *testing.T) {
+	text := `program Calls;
+var a : integer;
+
+function F(x : integer) : integer;
+begin
+end;
+
+procedure P;
+begin
+end;
+
+begin
+  a := F(1);
+  a := F(1, 2);
+  a := G(1);
+  a := P(1);
+  F := 1
+end.`
+	expectErrors(t, text, []string{
+		"14:8: wrong number of arguments to F, got 2 and expected 1",
+		"15:8: function G undeclared",
+		"16:8: P is not a function",
+		"17:3: cannot assign to function F outside of its body",
+	})
+}
+
+func TestScopedLookup(t *testing.T) {
+	global := NewScopedSymbolTable("global", 1, nil)
+	global.Define(VarSymbol{Name: "x", Type: "INTEGER"})
+	local := NewScopedSymbolTable("P", 2, global)
+	local.Define(VarSymbol{Name: "y", Type: "REAL"})
+
+	if symbol := local.Lookup("X", false); symbol == nil || symbol.ShowType() != "INTEGER" {
+		t.Errorf("lookup of x is %+v; expected the global x\n ", symbol)
+	}
+	if symbol := local.Lookup("x", true); symbol != nil {
+		t.Errorf("lookup of x in the current scope only is %+v; expected nil\n ", symbol)
+	}
+	if symbol := global.Lookup("y", false); symbol != nil {
+		t.Errorf("lookup of y in the global scope is %+v; expected nil\n ", symbol)
+	}
+}
+
+func TestProcedureScope(t *testing.T) {
+	text := `program Scopes;
+var x, i : integer;
+
+procedure P(a : integer; const c : integer; a : real);
+var x : real;
+    y : integer;
+    y : integer;
+begin
+  x := 1.5;
+  c := 2;
+  for i := 1 to 2 do
+    y := i
+end;
+
+function F(n : integer) : integer;
+  function G : integer;
+  begin
+    F := 1;
+    G := 2
+  end;
+begin
+  F := n + G
+end;
+
+procedure P;
+begin
+end;
+
+begin
+  x := F(1);
+  y := 2
+end.`
+	expectErrors(t, text, []string{
+		"4:45: Duplicate  identifier a",
+		"7:5: Duplicate  identifier y",
+		"10:3: cannot assign to CONST parameter c",
+		"11:7: FOR control variable i must be a local variable",
+		"25:1: Duplicate  identifier P",
+		"31:3: varname y undeclared",
+	})
+}
+
+func TestRoutineOrder(t *testing.T) {
+	text := `program Order;
+var x : integer;
+
+function F : integer;
+begin
+  F := 1
+end;
+
+procedure Q;
+begin
+  x := F;
+  x := G
+end;
+
+function G : integer;
+begin
+  G := F
+end;
+
+function Fact(n : integer) : integer;
+begin
+  Fact := 1
+end;
+
+begin
+  Q;
+  x := G;
+  x := Fact
+end.`
+	expectErrors(t, text, []string{
+		"12:8: varname G undeclared",
+		"28:8: wrong number of arguments to Fact, got 0 and expected 1",
+	})
+}
+
+func TestFunctionResultVariable(t *testing.T) {
+	text := `{$mode objfpc}
+program Results;
+var x : integer;
+
+function Twice(n : integer) : integer;
+begin
+  Result := n + n
+end;
+
+begin
+  x := Twice(1);
+  Result := 1
+end.`
+	expectErrors(t, text, []string{"12:3: varname Result undeclared"})
+}
+
+func TestTypeChecker(t *testing.T) {
+	text := `program Typed;
+var i : integer;
+    r : real;
+    c : char;
+    s : string;
+    b : boolean;
+begin
+  r := i;
+  i := r;
+  i := 7 / 2;
+  i := 7 div 2;
+  r := r div 2;
+  s := c + 'x';
+  i := s + 1;
+  b := i < r;
+  b := s = 1;
+  b := not i;
+  c := -c;
+  i := i and b
+end.`
+	expectErrors(t, text, []string{
+		"9:8: cannot assign REAL to INTEGER variable i",
+		"10:8: result of / is always REAL, cannot assign it to INTEGER variable i",
+		"12:10: operator div not applicable to REAL and INTEGER",
+		"14:10: operator + not applicable to STRING and INTEGER",
+		"16:10: operator = not applicable to STRING and INTEGER",
+		"17:8: cannot assign INTEGER to BOOLEAN variable b",
+		"18:8: operator - not applicable to CHAR",
+		"19:10: operator and not applicable to INTEGER and BOOLEAN",
+	})
+}
+
+func TestRecordedTypes(t *testing.T) {
+	text := `program Typed;
+var i : integer;
+    r : real;
+begin
+  r := i * 2 + r / 2
+end.`
+	lexer := lexer.NewLexer(text)
+	parser := parser.NewParser(lexer)
+	symtab := NewSymbolTable()
+	symtab.InitBuiltins()
+	tree, err := parser.Program()
+	if err != nil {
+		t.Fatal(err)
+	}
+	symtab.Visit(tree)
+
+	program := tree.(ast.Program)
+	assign := program.Block.Compound.Children[0].(ast.Statement).Statement.(ast.AssignStatement)
+	sum := assign.Right.(ast.BinNode)
+	expect := []struct {
+		node ast.Expr
+		want string
+	}{
+		{sum, "REAL"},
+		{sum.Left, "INTEGER"},
+		{sum.Left.(ast.BinNode).Left, "INTEGER"},
+		{sum.Right, "REAL"},
+		{sum.Right.(ast.BinNode).Left, "REAL"},
+	}
+	for _, e := range expect {
+		if got := symtab.TypeOf(e.node); got != e.want {
+			t.Errorf("type of %s is %q; expected  %q\n ", e.node.ToStr(), got, e.want)
+		}
+	}
+}
+
+func TestIntegerDivision(t *testing.T) {
+	text := `program Division;
+var i : integer;
+    r : real;
+begin
+  i := 7 div 2 + 7 mod 2;
+  r := 7 / 2;
+  r := 7 div 2;
+  i := r mod 2;
+  i := 2 mod r;
+  i := 8 / 4
+end.`
+	expectErrors(t, text, []string{
+		"8:10: operator mod not applicable to REAL and INTEGER",
+		"9:10: operator mod not applicable to INTEGER and REAL",
+		"10:8: result of / is always REAL, cannot assign it to INTEGER variable i",
+	})
+}
+
+func TestDiagnosticCodes(t *testing.T) {
+	text := `program Codes;
+var x : integer;
+    x : real;
+procedure P;
+begin
+end;
+begin
+  y := 1;
+  x := P;
+  x := 'a';
+  P(1);
+  x(2)
+end.`
+	expect := []struct {
+		code diagnostics.Code
+		span string
+	}{
+		{diagnostics.DuplicateIdentifier, "3:5-3:6"},
+		{diagnostics.Undeclared, "8:3-8:4"},
+		{diagnostics.WrongKind, "9:8-9:9"},
+		{diagnostics.TypeMismatch, "10:8-10:11"},
+		{diagnostics.ArgumentCount, "11:3-11:7"},
+		{diagnostics.WrongKind, "12:3-12:7"},
+	}
+	errList := check(text)
+	if len(errList) != len(expect) {
+		t.Fatalf("errors are %+v; expected  %+v\n ", errList, expect)
+	}
+	for i, want := range expect {
+		var semErr *diagnostics.SemanticError
+		if !errors.As(errList[i], &semErr) {
+			t.Fatalf("error %+v is no semantic error\n ", errList[i])
+		}
+		span := fmt.Sprintf("%s-%s", semErr.Span.Start, semErr.Span.End)
+		if semErr.Code != want.code || span != want.span {
+			t.Errorf("error %+v is %s at %s; expected  %s at %s\n ", semErr, semErr.Code, span, want.code, want.span)
+		}
+	}
+}
+
+func TestReadBeforeAssigned(t *testing.T) {
+	text := `program Flow;
+var a, b, c, d, e, f, g, x : integer;
+procedure SetG;
+begin
+  g := 1
+end;
+procedure Get(var n : integer);
+begin
+  n := 2
+end;
+begin
+  x := a;
+  if x > 0 then b := 1 else b := 2;
+  if x > 0 then c := 1;
+  while x > 0 do d := 1;
+  repeat e := 1 until e > 0;
+  case x of
+    1: f := 1;
+    2: f := 2
+  else
+    f := 3
+  end;
+  Get(x);
+  SetG;
+  x := a + b + c + d + e + f + g;
+  for x := 1 to 2 do a := x;
+  x := x + 1
+end.`
+	expectWarnings(t, text, []string{
+		"12:8: warning: variable a may be read before it is assigned",
+		"25:16: warning: variable c may be read before it is assigned",
+		"25:20: warning: variable d may be read before it is assigned",
+		"27:8: warning: variable x may be read before it is assigned",
+	})
+}
+
+func TestUnusedVariables(t *testing.T) {
+	text := `program Usage;
+var used, unused, written, shared : integer;
+procedure P;
+var local, count : integer;
+begin
+  count := shared;
+  for local := 1 to 2 do ;
+  used := count
+end;
+begin
+  shared := 1;
+  written := 2;
+  P;
+  used := used + 1
+end.`
+	expectWarnings(t, text, []string{
+		"2:11: warning: variable unused is declared but never used",
+		"2:19: warning: variable written is assigned but never read",
+	})
+	var semErr *diagnostics.SemanticError
+	errList := checkSeverity(text, diagnostics.Warning)
+	if !errors.As(errList[0], &semErr) || semErr.Code != diagnostics.UnusedVariable {
+		t.Errorf("error %+v is no %s warning\n ", errList[0], diagnostics.UnusedVariable)
+	}
+}
+
+func TestConstants(t *testing.T) {
+	text := `program Consts;
+const Max = 100;
+      Half = Max div 2;
+      Pi2 = 2 * 3.14159;
+      Greeting = 'hello' + ', ' + 'world';
+      Big : real = Max;
+      Flag : boolean = 1;
+      Zero = Max mod (Half - 50);
+var i : integer;
+    r : real;
+procedure P(var n : integer);
+const Local = i + 1;
+begin
+  n := Max
+end;
+begin
+  i := Half;
+  r := Pi2 + Big;
+  Max := 1;
+  P(Max);
+  for Half := 1 to 2 do ;
+  case i of
+    Half: i := 0;
+    Half + 1..Max: i := 1;
+    100: i := 2
+  end
+end.`
+	expectErrors(t, text, []string{
+		"7:24: cannot assign INTEGER to BOOLEAN constant Flag",
+		"8:14: division by zero in constant expression",
+		"12:15: value of constant Local must be a constant expression",
+		"19:3: cannot assign to constant Max",
+		"20:5: argument for VAR parameter n of P must be a variable",
+		"21:7: FOR control variable Half must be a local variable",
+		"25:5: duplicate case label, overlaps the label at 24:5",
+	})
+}
+
+func TestTypeDeclarations(t *testing.T) {
+	text := `program Types;
+const Max = 10;
+type Index = 1..Max;
+     Score = integer;
+     Rank = Index;
+     Letter = 'a'..'z';
+     Empty = 5..1;
+     Mixed = 1..'z';
+     Bad = Max;
+var i : Index;
+    s : Score;
+    r : Rank;
+    c : Letter;
+    u : Unknown;
+procedure P(n : Index);
+begin
+end;
+begin
+  s := i + r;
+  i := 5;
+  i := 11;
+  r := 0;
+  c := 'A';
+  P(Max);
+  P(20);
+  for i := 0 to Max do ;
+  Score := 1;
+  s := Index
+end.`
+	expectErrors(t, text, []string{
+		"7:6: subrange Empty is empty",
+		"8:6: bounds of subrange Mixed must be of the same type, got INTEGER and CHAR",
+		"9:6: Max is not a type",
+		"14:5: type Unknown undeclared",
+		"21:8: constant 11 is out of range 1..10 of variable i",
+		"22:8: constant 0 is out of range 1..10 of variable r",
+		"23:8: constant 'A' is out of range 'a'..'z' of variable c",
+		"25:5: constant 20 is out of range 1..10 of parameter n of P",
+		"26:12: constant 0 is out of range 1..10 of control variable i",
+		"27:3: cannot assign to type Score",
+		"28:8: type Index used as a value",
+	})
+}
+
+func TestEnumeratedTypes(t *testing.T) {
+	text := `program Enums;
+type Color = (Red, Green, Blue);
+     Fruit = (Apple, Red);
+     Warm = Red..Green;
+const Last = High(Color);
+      Second = Succ(Low(Color));
+      Count = Ord(Last) + 1;
+var c : Color;
+    w : Warm;
+    f : Fruit;
+    i : integer;
+    r : real;
+begin
+  c := 1;
+  i := Red + 1;
+  c := Succ(Last);
+  c := Pred(Red, Green);
+  i := Ord(r);
+  if c = f then i := Count;
+  w := Blue;
+  w := Pred(High(w));
+  Ord := 3;
+  i := Ord;
+  for c := Red to Last do
+    case c of
+      Red: i := 1;
+      Second..Blue: i := 2;
+      Apple: i := 3
+    end
+end.`
+	expectErrors(t, text, []string{
+		"3:22: Duplicate  identifier Red",
+		"14:8: cannot assign INTEGER to Color variable c",
+		"15:12: operator + not applicable to Color and INTEGER",
+		"16:8: Succ(Blue) is out of the values of Color",
+		"17:8: wrong number of arguments to Pred, got 2 and expected 1",
+		"18:12: argument of Ord must be of an ordinal type, got REAL",
+		"19:8: operator = not applicable to Color and Fruit",
+		"20:8: constant Blue is out of range Red..Green of variable w",
+		"22:3: cannot assign to standard function Ord",
+		"23:8: wrong number of arguments to Ord, got 0 and expected 1",
+		"28:7: case label of type Fruit does not match selector of type Color",
+	})
+}
+
+func TestBuiltinTypeBounds(t *testing.T) {
+	text := `program Bounds;
+type Digit = 0..9;
+const Top = High(Char);
+var d : Digit;
+    c : char;
+    b : boolean;
+    r : real;
+begin
+  d := Ord(Top);
+  d := Ord(High(Boolean));
+  d := Low(Integer);
+  b := Low(Boolean);
+  c := High(Char);
+  r := High(Real);
+  d := Low(String)
+end.`
+	expectErrors(t, text, []string{
+		"9:8: constant 255 is out of range 0..9 of variable d",
+		"11:8: constant -9223372036854775808 is out of range 0..9 of variable d",
+		"14:13: argument of High must be of an ordinal type, got REAL",
+		"15:12: argument of Low must be of an ordinal type, got STRING",
+	})
+}